@@ -0,0 +1,197 @@
+package main
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+// openArchive reads a .zip, .tar, .tar.gz or .tgz file from fsys and returns
+// a read-only view of its contents.
+func openArchive(fsys fileSystem, name string) (fs.FS, error) {
+	data, err := fsys.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+
+	switch lower := strings.ToLower(name); {
+	case strings.HasSuffix(lower, ".zip"):
+		return zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	case strings.HasSuffix(lower, ".tar"):
+		return newTarFS(bytes.NewReader(data))
+	case strings.HasSuffix(lower, ".tar.gz"), strings.HasSuffix(lower, ".tgz"):
+		zr, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		defer zr.Close()
+		return newTarFS(zr)
+	default:
+		return nil, fmt.Errorf("unsupported archive type (want .zip, .tar, .tar.gz or .tgz)")
+	}
+}
+
+// tarFS is an fs.FS over the regular files and directories of a tar stream.
+// Missing parent directories are synthesized, as zip.Reader does.
+type tarFS struct {
+	files map[string]*tarEntry
+}
+
+type tarEntry struct {
+	name     string
+	data     []byte
+	mode     fs.FileMode
+	modTime  time.Time
+	children []string
+}
+
+func newTarFS(r io.Reader) (*tarFS, error) {
+	t := &tarFS{files: map[string]*tarEntry{
+		".": {name: ".", mode: fs.ModeDir | 0555},
+	}}
+
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return t, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		name := strings.TrimPrefix(path.Clean(hdr.Name), "/")
+		if name == "." || !fs.ValidPath(name) {
+			continue
+		}
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			t.add(name, &tarEntry{mode: fs.ModeDir | fs.FileMode(hdr.Mode).Perm(), modTime: hdr.ModTime})
+		case tar.TypeReg:
+			data, err := io.ReadAll(tr)
+			if err != nil {
+				return nil, err
+			}
+			t.add(name, &tarEntry{data: data, mode: fs.FileMode(hdr.Mode).Perm(), modTime: hdr.ModTime})
+		}
+	}
+}
+
+// add records entry under name, creating parent directories as needed. A
+// later entry with the same name replaces the earlier one.
+func (t *tarFS) add(name string, entry *tarEntry) {
+	entry.name = path.Base(name)
+	if old, ok := t.files[name]; ok {
+		entry.children = old.children
+		t.files[name] = entry
+		return
+	}
+	t.files[name] = entry
+
+	dir := path.Dir(name)
+	if _, ok := t.files[dir]; !ok {
+		t.add(dir, &tarEntry{mode: fs.ModeDir | 0555})
+	}
+	parent := t.files[dir]
+	parent.children = append(parent.children, entry.name)
+}
+
+func (t *tarFS) lookup(op, name string) (*tarEntry, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+	}
+	entry, ok := t.files[name]
+	if !ok {
+		return nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrNotExist}
+	}
+	return entry, nil
+}
+
+func (t *tarFS) Open(name string) (fs.File, error) {
+	entry, err := t.lookup("open", name)
+	if err != nil {
+		return nil, err
+	}
+	return &tarFile{fsys: t, path: name, entry: entry, r: bytes.NewReader(entry.data)}, nil
+}
+
+func (t *tarFS) ReadFile(name string) ([]byte, error) {
+	entry, err := t.lookup("open", name)
+	if err != nil {
+		return nil, err
+	}
+	if entry.IsDir() {
+		return nil, &fs.PathError{Op: "read", Path: name, Err: errIsDir}
+	}
+	return bytes.Clone(entry.data), nil
+}
+
+func (t *tarFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	entry, err := t.lookup("readdir", name)
+	if err != nil {
+		return nil, err
+	}
+	if !entry.IsDir() {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: errors.New("not a directory")}
+	}
+	return t.entries(name, entry), nil
+}
+
+func (t *tarFS) entries(dir string, entry *tarEntry) []fs.DirEntry {
+	entries := make([]fs.DirEntry, 0, len(entry.children))
+	for _, child := range entry.children {
+		entries = append(entries, fs.FileInfoToDirEntry(t.files[path.Join(dir, child)]))
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+	return entries
+}
+
+func (e *tarEntry) Name() string       { return e.name }
+func (e *tarEntry) Size() int64        { return int64(len(e.data)) }
+func (e *tarEntry) Mode() fs.FileMode  { return e.mode }
+func (e *tarEntry) ModTime() time.Time { return e.modTime }
+func (e *tarEntry) IsDir() bool        { return e.mode.IsDir() }
+func (e *tarEntry) Sys() any           { return nil }
+
+// tarFile is an open file or directory of a tarFS.
+type tarFile struct {
+	fsys    *tarFS
+	path    string
+	entry   *tarEntry
+	r       *bytes.Reader
+	dirRead int
+}
+
+func (f *tarFile) Stat() (fs.FileInfo, error) { return f.entry, nil }
+func (f *tarFile) Close() error               { return nil }
+
+func (f *tarFile) Read(p []byte) (int, error) {
+	if f.entry.IsDir() {
+		return 0, &fs.PathError{Op: "read", Path: f.path, Err: errIsDir}
+	}
+	return f.r.Read(p)
+}
+
+func (f *tarFile) ReadDir(n int) ([]fs.DirEntry, error) {
+	if !f.entry.IsDir() {
+		return nil, &fs.PathError{Op: "readdir", Path: f.path, Err: errors.New("not a directory")}
+	}
+	entries := f.fsys.entries(f.path, f.entry)[f.dirRead:]
+	if n > 0 {
+		if len(entries) == 0 {
+			return nil, io.EOF
+		}
+		entries = entries[:min(n, len(entries))]
+	}
+	f.dirRead += len(entries)
+	return entries, nil
+}
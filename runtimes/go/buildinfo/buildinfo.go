@@ -0,0 +1,79 @@
+// Package buildinfo describes how a runtime binary was built. The values
+// are compiled in by scripts/build-go.sh through -ldflags -X, and the same
+// record is embedded in the binary's wasmhub.buildinfo custom section so it
+// can be read without running the module.
+package buildinfo
+
+import (
+	"encoding/json"
+	"runtime"
+
+	"github.com/anistark/wasmhub/runtimes/go/wasm"
+)
+
+// SectionName is the custom section that carries the JSON-encoded Info.
+const SectionName = "wasmhub.buildinfo"
+
+// Set with -ldflags "-X github.com/anistark/wasmhub/runtimes/go/buildinfo.version=1.23"
+// and so on. A plain go or tinygo build leaves them at their defaults.
+var (
+	version      = "dev"
+	tinygo       string
+	llvm         string
+	target       string
+	commit       string
+	date         string
+	optimization string
+)
+
+// Info is the build metadata of a runtime binary.
+type Info struct {
+	Version      string `json:"version"`
+	GoVersion    string `json:"go_version,omitempty"`
+	TinyGo       string `json:"tinygo,omitempty"`
+	LLVM         string `json:"llvm,omitempty"`
+	Target       string `json:"target"`
+	Commit       string `json:"commit,omitempty"`
+	Date         string `json:"date,omitempty"`
+	Optimization string `json:"optimization,omitempty"`
+}
+
+// Current returns the metadata compiled into this binary.
+func Current() Info {
+	info := Info{
+		Version:      version,
+		GoVersion:    runtime.Version(),
+		TinyGo:       tinygo,
+		LLVM:         llvm,
+		Target:       target,
+		Commit:       commit,
+		Date:         date,
+		Optimization: optimization,
+	}
+	if info.Target == "" {
+		info.Target = runtime.GOOS + "/" + runtime.GOARCH
+	}
+	return info
+}
+
+// Embed returns a copy of the wasm module data carrying info in its
+// wasmhub.buildinfo custom section, replacing any previous one.
+func Embed(data []byte, info Info) ([]byte, error) {
+	payload, err := json.Marshal(info)
+	if err != nil {
+		return nil, err
+	}
+	return wasm.SetCustomSection(data, SectionName, payload)
+}
+
+// FromModule decodes the wasmhub.buildinfo section of m. It reports false
+// when the module has none.
+func FromModule(m *wasm.Module) (Info, bool, error) {
+	var info Info
+	data, ok := m.CustomSection(SectionName)
+	if !ok {
+		return info, false, nil
+	}
+	err := json.Unmarshal(data, &info)
+	return info, true, err
+}
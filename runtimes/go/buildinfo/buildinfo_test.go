@@ -0,0 +1,37 @@
+package buildinfo
+
+import (
+	"testing"
+
+	"github.com/anistark/wasmhub/runtimes/go/wasm"
+)
+
+func TestEmbedRoundTrip(t *testing.T) {
+	want := Info{Version: "1.23", GoVersion: "go1.23.0", TinyGo: "0.33.0", LLVM: "18.1.2", Target: "wasip1", Commit: "0123456789ab"}
+	data, err := Embed([]byte("\x00asm\x01\x00\x00\x00"), want)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := wasm.Parse(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, ok, err := FromModule(m)
+	if err != nil || !ok {
+		t.Fatalf("FromModule = %v, %v", ok, err)
+	}
+	if got != want {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+
+	if _, ok, _ := FromModule(&wasm.Module{}); ok {
+		t.Error("FromModule found build info in a module without it")
+	}
+}
+
+func TestCurrentDefaults(t *testing.T) {
+	info := Current()
+	if info.Version != "dev" || info.GoVersion == "" || info.Target == "" {
+		t.Errorf("Current() = %+v, want version dev with Go version and target filled in", info)
+	}
+}
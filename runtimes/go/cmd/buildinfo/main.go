@@ -0,0 +1,123 @@
+// Command buildinfo writes and reads the wasmhub.buildinfo custom section
+// of a runtime binary.
+//
+// Usage:
+//
+//	buildinfo embed --file build/go/go-1.23.wasm --version 1.23 --target wasip1 --commit abc123
+//	buildinfo show build/go/go-1.23.wasm
+//
+// scripts/build-go.sh runs embed after wasm-opt, with the same values it
+// passes to the binary through -ldflags -X.
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/anistark/wasmhub/runtimes/go/buildinfo"
+	"github.com/anistark/wasmhub/runtimes/go/wasm"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(1)
+	}
+
+	var err error
+	switch os.Args[1] {
+	case "embed":
+		err = embed(os.Args[2:])
+	case "show":
+		err = show(os.Args[2:])
+	case "-h", "--help", "help":
+		usage()
+		return
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown command: %s\n", os.Args[1])
+		usage()
+		os.Exit(1)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "Usage: buildinfo <command> [flags]")
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "Commands:")
+	fmt.Fprintln(os.Stderr, "  embed  Write build metadata into a wasm file's "+buildinfo.SectionName+" section")
+	fmt.Fprintln(os.Stderr, "  show   Print the build metadata embedded in wasm files")
+}
+
+func embed(args []string) error {
+	fset := flag.NewFlagSet("embed", flag.ExitOnError)
+	file := fset.String("file", "", "wasm file to update in place")
+	var info buildinfo.Info
+	fset.StringVar(&info.Version, "version", "", "runtime version label")
+	fset.StringVar(&info.GoVersion, "go-version", "", "Go version the toolchain compiled against")
+	fset.StringVar(&info.TinyGo, "tinygo", "", "TinyGo version")
+	fset.StringVar(&info.LLVM, "llvm", "", "LLVM version")
+	fset.StringVar(&info.Target, "target", "", "TinyGo target")
+	fset.StringVar(&info.Commit, "commit", "", "git commit the binary was built from")
+	fset.StringVar(&info.Date, "date", "", "build date (RFC 3339)")
+	fset.StringVar(&info.Optimization, "optimization", "", "optimization settings")
+	fset.Parse(args)
+
+	if *file == "" || info.Version == "" {
+		return errors.New("--file and --version are required")
+	}
+	data, err := os.ReadFile(*file)
+	if err != nil {
+		return err
+	}
+	if data, err = buildinfo.Embed(data, info); err != nil {
+		return fmt.Errorf("%s: %w", *file, err)
+	}
+	tmp := *file + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, *file); err != nil {
+		return err
+	}
+	fmt.Printf("Embedded %s section in %s\n", buildinfo.SectionName, *file)
+	return nil
+}
+
+func show(paths []string) error {
+	if len(paths) == 0 {
+		return errors.New("show requires at least one wasm file")
+	}
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		m, err := wasm.Parse(data)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		info, ok, err := buildinfo.FromModule(m)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		if !ok {
+			return fmt.Errorf("%s has no %s section", path, buildinfo.SectionName)
+		}
+		out, err := json.MarshalIndent(info, "", "  ")
+		if err != nil {
+			return err
+		}
+		if len(paths) > 1 {
+			fmt.Printf("%s:\n", path)
+		}
+		fmt.Println(string(out))
+	}
+	return nil
+}
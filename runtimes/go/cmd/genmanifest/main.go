@@ -0,0 +1,297 @@
+// Command genmanifest creates and updates the manifest.json files that
+// describe wasmhub's runtimes, and regenerates the global manifest at the
+// repository root from them.
+//
+// Usage:
+//
+//	genmanifest update --language go --version 1.23 --file runtimes/go/go-1.23.wasm
+//	genmanifest validate runtimes/go/manifest.json runtimes/rust/manifest.json
+//	genmanifest features runtimes/go/go-1.23.wasm
+//	genmanifest global --root .
+//
+// Unless --features is given, update derives a version's features from the
+// WASI preview 1 functions the wasm file imports. The released timestamp
+// honours SOURCE_DATE_EPOCH for reproducible builds.
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/anistark/wasmhub/runtimes/go/manifest"
+	"github.com/anistark/wasmhub/runtimes/go/wasm"
+)
+
+// knownSources are the upstream source and license recorded in the global
+// manifest when a language is listed for the first time.
+var knownSources = map[string][2]string{
+	"go":   {"https://github.com/tinygo-org/tinygo", "BSD-3-Clause"},
+	"rust": {"https://github.com/rust-lang/rust", "MIT OR Apache-2.0"},
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(1)
+	}
+
+	var err error
+	switch os.Args[1] {
+	case "update":
+		err = update(os.Args[2:])
+	case "validate":
+		err = validate(os.Args[2:])
+	case "features":
+		err = listFeatures(os.Args[2:])
+	case "global":
+		err = global(os.Args[2:])
+	case "-h", "--help", "help":
+		usage()
+		return
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown command: %s\n", os.Args[1])
+		usage()
+		os.Exit(1)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "Usage: genmanifest <command> [flags]")
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "Commands:")
+	fmt.Fprintln(os.Stderr, "  update    Add a wasm file to its runtime's manifest.json")
+	fmt.Fprintln(os.Stderr, "  validate  Check manifest.json files against the schema")
+	fmt.Fprintln(os.Stderr, "  features  Print the features derived from wasm files' WASI imports")
+	fmt.Fprintln(os.Stderr, "  global    Regenerate the global manifest from runtimes/*/manifest.json")
+}
+
+func update(args []string) error {
+	fset := flag.NewFlagSet("update", flag.ExitOnError)
+	language := fset.String("language", "", "language (go, rust, nodejs, python, ruby, php)")
+	version := fset.String("version", "", "runtime version")
+	file := fset.String("file", "", "path to the wasm file; its manifest.json is written alongside")
+	wasi := fset.String("wasi", "wasip1", "WASI version")
+	features := fset.String("features", "", "comma-separated features (default: derived from the file's WASI imports)")
+	fset.Parse(args)
+
+	if *language == "" || *version == "" || *file == "" {
+		return errors.New("--language, --version and --file are required")
+	}
+	if !manifest.ValidVersion(*version) {
+		return fmt.Errorf("invalid version %q", *version)
+	}
+
+	data, err := os.ReadFile(*file)
+	if err != nil {
+		return err
+	}
+	released, err := releaseTime()
+	if err != nil {
+		return err
+	}
+	sum := sha256.Sum256(data)
+
+	featureList := splitFeatures(*features)
+	if *features == "" {
+		if featureList, err = wasmFeatures(data); err != nil {
+			return fmt.Errorf("%s: %w", *file, err)
+		}
+	}
+
+	path := filepath.Join(filepath.Dir(*file), "manifest.json")
+	m := &manifest.Runtime{Language: *language}
+	if existing, err := os.ReadFile(path); err == nil {
+		if m, err = manifest.Parse(existing); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		if m.Language != *language {
+			return fmt.Errorf("%s is for %s, not %s", path, m.Language, *language)
+		}
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	m.SetVersion(*version, manifest.Version{
+		File:     filepath.Base(*file),
+		Size:     int64(len(data)),
+		SHA256:   hex.EncodeToString(sum[:]),
+		Released: released.Format(time.RFC3339),
+		WASI:     *wasi,
+		Features: featureList,
+	})
+	if err := m.Validate(); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	if err := writeJSON(path, m.Marshal); err != nil {
+		return err
+	}
+
+	fmt.Println("Metadata generated:")
+	fmt.Printf("  Manifest: %s\n", path)
+	fmt.Printf("  Language: %s\n", m.Language)
+	fmt.Printf("  Version: %s (latest: %s)\n", *version, m.Latest)
+	fmt.Printf("  Size: %d bytes\n", len(data))
+	fmt.Printf("  SHA256: %s\n", hex.EncodeToString(sum[:]))
+	fmt.Printf("  Features: %s\n", strings.Join(featureList, ", "))
+	return nil
+}
+
+func listFeatures(paths []string) error {
+	if len(paths) == 0 {
+		return errors.New("features requires at least one wasm file")
+	}
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		features, err := wasmFeatures(data)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		fmt.Printf("%s: %s\n", path, strings.Join(features, ", "))
+	}
+	return nil
+}
+
+// wasmFeatures parses a wasm file and maps its WASI imports to features.
+func wasmFeatures(data []byte) ([]string, error) {
+	m, err := wasm.Parse(data)
+	if err != nil {
+		return nil, err
+	}
+	return wasm.Features(m), nil
+}
+
+func validate(paths []string) error {
+	if len(paths) == 0 {
+		return errors.New("validate requires at least one manifest.json")
+	}
+	failed := 0
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err == nil {
+			var m *manifest.Runtime
+			if m, err = manifest.Parse(data); err == nil {
+				err = m.Validate()
+			}
+		}
+		if err != nil {
+			fmt.Printf("FAIL %s\n", path)
+			for _, line := range strings.Split(err.Error(), "\n") {
+				fmt.Printf("  %s\n", line)
+			}
+			failed++
+			continue
+		}
+		fmt.Printf("OK %s\n", path)
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d manifests invalid", failed, len(paths))
+	}
+	return nil
+}
+
+func global(args []string) error {
+	fset := flag.NewFlagSet("global", flag.ExitOnError)
+	root := fset.String("root", ".", "repository root containing runtimes/")
+	hubVersion := fset.String("hub-version", "1.0.0", "manifest version used when creating the global manifest")
+	fset.Parse(args)
+
+	path := filepath.Join(*root, "manifest.json")
+	g := &manifest.Global{Version: *hubVersion}
+	if existing, err := os.ReadFile(path); err == nil {
+		if g, err = manifest.ParseGlobal(existing); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	runtimes, err := filepath.Glob(filepath.Join(*root, "runtimes", "*", "manifest.json"))
+	if err != nil {
+		return err
+	}
+	sort.Strings(runtimes)
+
+	listed := make(map[string]bool)
+	for _, rpath := range runtimes {
+		data, err := os.ReadFile(rpath)
+		if err != nil {
+			return err
+		}
+		m, err := manifest.Parse(data)
+		if err == nil {
+			err = m.Validate()
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", rpath, err)
+		}
+		src := knownSources[m.Language]
+		g.SetLanguage(m, src[0], src[1])
+		listed[m.Language] = true
+	}
+	for lang := range g.Languages {
+		if !listed[lang] {
+			delete(g.Languages, lang)
+		}
+	}
+
+	if err := writeJSON(path, g.Marshal); err != nil {
+		return err
+	}
+	fmt.Printf("Global manifest: %s (%d languages)\n", path, len(g.Languages))
+	return nil
+}
+
+// releaseTime is SOURCE_DATE_EPOCH when set, so rebuilding the same commit
+// yields the same manifest, and the current time otherwise.
+func releaseTime() (time.Time, error) {
+	epoch := os.Getenv("SOURCE_DATE_EPOCH")
+	if epoch == "" {
+		return time.Now().UTC().Truncate(time.Second), nil
+	}
+	secs, err := strconv.ParseInt(epoch, 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid SOURCE_DATE_EPOCH %q", epoch)
+	}
+	return time.Unix(secs, 0).UTC(), nil
+}
+
+func splitFeatures(s string) []string {
+	features := []string{}
+	for _, f := range strings.Split(s, ",") {
+		if f = strings.TrimSpace(f); f != "" {
+			features = append(features, f)
+		}
+	}
+	return features
+}
+
+// writeJSON writes the encoded manifest through a temporary file so a
+// failed run never leaves a truncated manifest behind.
+func writeJSON(path string, marshal func() ([]byte, error)) error {
+	data, err := marshal()
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
@@ -0,0 +1,204 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+func registerBuiltins(r *registry) {
+	r.register(&command{
+		name:    "help",
+		args:    "[command]",
+		maxArgs: 1,
+		summary: "Show help for the runtime or a command",
+		run: func(e *environment, args []string) int {
+			if len(args) == 0 {
+				r.printUsage(e.stdout)
+				return 0
+			}
+			cmd := r.lookup(args[0])
+			if cmd == nil {
+				fmt.Fprintf(e.stderr, "Unknown command: %s\n", args[0])
+				return 1
+			}
+			cmd.printHelp(e.stdout)
+			return 0
+		},
+	})
+	version := r.register(&command{
+		name:    "version",
+		maxArgs: 0,
+		summary: "Print runtime version and build info",
+	})
+	versionJSON := version.flags.Bool("json", false, "print build info as JSON")
+	version.run = func(e *environment, _ []string) int { return printVersion(e, *versionJSON) }
+	r.register(&command{
+		name:    "eval",
+		args:    "<expr>",
+		minArgs: 1,
+		maxArgs: 1,
+		summary: "Evaluate a simple expression",
+		rawArgs: true,
+		run:     func(e *environment, args []string) int { return eval(e, args[0]) },
+	})
+	registerEnv(r)
+	registerPrintenv(r)
+	r.register(&command{
+		name:    "echo",
+		args:    "[args...]",
+		maxArgs: -1,
+		summary: "Print arguments to stdout",
+		rawArgs: true,
+		run:     echo,
+	})
+	r.register(&command{
+		name:    "cat",
+		args:    "<file>",
+		minArgs: 1,
+		maxArgs: 1,
+		summary: "Print file contents",
+		run:     func(e *environment, args []string) int { return catFile(e, args[0]) },
+	})
+	r.register(&command{
+		name:    "ls",
+		args:    "[path]",
+		maxArgs: 1,
+		summary: "List directory contents",
+		run: func(e *environment, args []string) int {
+			path := "."
+			if len(args) > 0 {
+				path = args[0]
+			}
+			return listDir(e, path)
+		},
+	})
+	r.register(&command{
+		name:    "write",
+		args:    "<file> <content>",
+		minArgs: 2,
+		maxArgs: 2,
+		summary: "Write content to file",
+		run:     func(e *environment, args []string) int { return writeFile(e, args[0], args[1]) },
+	})
+	registerWatch(r)
+	registerSigning(r)
+	registerWasmTools(r)
+	r.register(&command{
+		name:    "overlay",
+		args:    "<diff|commit|export> [file]",
+		minArgs: 1,
+		maxArgs: 2,
+		summary: "Inspect, commit or export writes held by --overlay",
+		run:     overlayCommand,
+	})
+}
+
+// targetNames describes the TinyGo targets the runtime is built for.
+var targetNames = map[string]string{
+	"wasip1": "WASI Preview 1",
+	"wasip2": "WASI Preview 2",
+}
+
+func printVersion(e *environment, asJSON bool) int {
+	info := e.build
+	if asJSON {
+		out, err := json.MarshalIndent(info, "", "  ")
+		if err != nil {
+			fmt.Fprintf(e.stderr, "Error encoding JSON: %v\n", err)
+			return 1
+		}
+		fmt.Fprintln(e.stdout, string(out))
+		return 0
+	}
+
+	fmt.Fprintln(e.stdout, "WasmHub Go Runtime")
+	fmt.Fprintf(e.stdout, "Version: %s\n", info.Version)
+	var toolchain []string
+	if info.TinyGo != "" {
+		toolchain = append(toolchain, "TinyGo "+info.TinyGo)
+	}
+	if info.LLVM != "" {
+		toolchain = append(toolchain, "LLVM "+info.LLVM)
+	}
+	if len(toolchain) > 0 {
+		fmt.Fprintf(e.stdout, "Go Version: %s (%s)\n", info.GoVersion, strings.Join(toolchain, ", "))
+	} else {
+		fmt.Fprintf(e.stdout, "Go Version: %s\n", info.GoVersion)
+	}
+	if name, ok := targetNames[info.Target]; ok {
+		fmt.Fprintf(e.stdout, "Target: %s (%s)\n", info.Target, name)
+	} else {
+		fmt.Fprintf(e.stdout, "Target: %s\n", info.Target)
+	}
+	if info.Commit != "" {
+		fmt.Fprintf(e.stdout, "Commit: %s\n", info.Commit)
+	}
+	if info.Date != "" {
+		fmt.Fprintf(e.stdout, "Built: %s\n", info.Date)
+	}
+	if info.Optimization != "" {
+		fmt.Fprintf(e.stdout, "Optimization: %s\n", info.Optimization)
+	}
+	fmt.Fprintln(e.stdout, "Features: filesystem, env, args, stdio")
+	return 0
+}
+
+func eval(e *environment, expr string) int {
+	fmt.Fprintf(e.stdout, "Evaluating: %s\n", expr)
+	fmt.Fprintln(e.stdout, "Note: Full eval requires a Go interpreter")
+	fmt.Fprintf(e.stdout, "Expression length: %d characters\n", len(expr))
+	return 0
+}
+
+func echo(e *environment, args []string) int {
+	for i, arg := range args {
+		if i > 0 {
+			fmt.Fprint(e.stdout, " ")
+		}
+		fmt.Fprint(e.stdout, arg)
+	}
+	fmt.Fprintln(e.stdout)
+	return 0
+}
+
+func catFile(e *environment, path string) int {
+	data, err := e.fs.ReadFile(path)
+	if err != nil {
+		fmt.Fprintf(e.stderr, "Error reading %s: %v\n", path, err)
+		return 1
+	}
+	e.stdout.Write(data)
+	return 0
+}
+
+func listDir(e *environment, path string) int {
+	entries, err := e.fs.ReadDir(path)
+	if err != nil {
+		fmt.Fprintf(e.stderr, "Error reading directory %s: %v\n", path, err)
+		return 1
+	}
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil {
+			fmt.Fprintln(e.stdout, entry.Name())
+			continue
+		}
+		typeChar := "-"
+		if entry.IsDir() {
+			typeChar = "d"
+		}
+		fmt.Fprintf(e.stdout, "%s %8d %s\n", typeChar, info.Size(), entry.Name())
+	}
+	return 0
+}
+
+func writeFile(e *environment, path, content string) int {
+	err := e.fs.WriteFile(path, []byte(content), 0644)
+	if err != nil {
+		fmt.Fprintf(e.stderr, "Error writing %s: %v\n", path, err)
+		return 1
+	}
+	fmt.Fprintf(e.stdout, "Wrote %d bytes to %s\n", len(content), path)
+	return 0
+}
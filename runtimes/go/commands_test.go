@@ -0,0 +1,193 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/anistark/wasmhub/runtimes/go/buildinfo"
+)
+
+var update = flag.Bool("update", false, "rewrite golden files in testdata")
+
+// memFS is an in-memory fileSystem. Writes fail with a permission error
+// when readOnly is set.
+type memFS struct {
+	files    fstest.MapFS
+	readOnly bool
+}
+
+func (m *memFS) ReadFile(name string) ([]byte, error) {
+	return m.files.ReadFile(path.Clean(name))
+}
+
+func (m *memFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	return m.files.ReadDir(path.Clean(name))
+}
+
+func (m *memFS) Stat(name string) (fs.FileInfo, error) {
+	return m.files.Stat(path.Clean(name))
+}
+
+func (m *memFS) WriteFile(name string, data []byte, perm fs.FileMode) error {
+	if m.readOnly {
+		return &fs.PathError{Op: "open", Path: name, Err: fs.ErrPermission}
+	}
+	m.files[path.Clean(name)] = &fstest.MapFile{Data: data, Mode: perm}
+	return nil
+}
+
+func newTestFS() *memFS {
+	return &memFS{files: fstest.MapFS{
+		"hello.txt":       {Data: []byte("Hello, WasmHub!\n")},
+		"empty.txt":       {Data: nil},
+		"src/main.go":     {Data: []byte("package main\n")},
+		"src/util/str.go": {Data: []byte("package util\n")},
+	}}
+}
+
+func newTestEnvironment(fsys fileSystem, stdout, stderr *bytes.Buffer) *environment {
+	return &environment{
+		stdin:   strings.NewReader(""),
+		stdout:  stdout,
+		stderr:  stderr,
+		fs:      fsys,
+		environ: []string{"HOME=/home/wasm", "PATH=/usr/bin", "LANG=C.UTF-8", "GITHUB_TOKEN=ghp_abc123"},
+		now:     func() time.Time { return time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC) },
+		sleep:   func(time.Duration) {},
+		rand:    bytes.NewReader(bytes.Repeat([]byte{0x42}, 64)),
+		build: buildinfo.Info{
+			Version:      "1.23",
+			GoVersion:    "go1.23.0",
+			TinyGo:       "0.33.0",
+			LLVM:         "18.1.2",
+			Target:       "wasip1",
+			Commit:       "0123456789ab",
+			Date:         "2026-01-02T03:04:05Z",
+			Optimization: "opt=2 no-debug, wasm-opt -O3",
+		},
+	}
+}
+
+// transcript runs args through dispatch and renders the exit code and both
+// output streams in the format stored in the golden files.
+func transcript(fsys fileSystem, args ...string) string {
+	var stdout, stderr bytes.Buffer
+	code := dispatch(newTestEnvironment(fsys, &stdout, &stderr), append([]string{progName}, args...))
+	return fmt.Sprintf("exit: %d\n-- stdout --\n%s-- stderr --\n%s", code, stdout.String(), stderr.String())
+}
+
+func checkGolden(t *testing.T, name, got string) {
+	t.Helper()
+	golden := filepath.Join("testdata", name+".golden")
+	if *update {
+		if err := os.WriteFile(golden, []byte(got), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatalf("reading golden file (run with -update to create it): %v", err)
+	}
+	if got != string(want) {
+		t.Errorf("output mismatch for %s\n--- got ---\n%s--- want ---\n%s", golden, got, want)
+	}
+}
+
+func TestCommandsGolden(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{"usage", nil},
+		{"unknown_command", []string{"frobnicate"}},
+		{"unknown_global_flag", []string{"--frobnicate", "ls"}},
+		{"help_command", []string{"help", "write"}},
+		{"help_unknown", []string{"help", "frobnicate"}},
+		{"version", []string{"version"}},
+		{"version_json", []string{"version", "--json"}},
+		{"version_global_flag", []string{"--version"}},
+		{"version_flag_help", []string{"version", "--help"}},
+		{"version_extra_args", []string{"version", "now"}},
+		{"version_unknown_flag", []string{"version", "--bogus"}},
+		{"env", []string{"env"}},
+		{"env_unknown_command", []string{"env", "HOME"}},
+		{"echo", []string{"echo", "hello", "wasm", "world"}},
+		{"echo_empty", []string{"echo"}},
+		{"echo_dashdash", []string{"echo", "--", "-n", "text"}},
+		{"echo_dash_args", []string{"echo", "-n", "hi"}},
+		{"echo_help", []string{"echo", "--help"}},
+		{"eval_negative", []string{"eval", "-1"}},
+		{"cat", []string{"cat", "hello.txt"}},
+		{"cat_empty", []string{"cat", "empty.txt"}},
+		{"cat_missing_arg", []string{"cat"}},
+		{"cat_not_found", []string{"cat", "missing.txt"}},
+		{"cat_extra_args", []string{"cat", "hello.txt", "empty.txt"}},
+		{"ls", []string{"ls"}},
+		{"ls_subdir", []string{"ls", "src"}},
+		{"ls_not_found", []string{"ls", "nowhere"}},
+		{"ls_unknown_flag", []string{"ls", "-l"}},
+		{"write", []string{"write", "out.txt", "some content"}},
+		{"write_missing_content", []string{"write", "out.txt"}},
+		{"write_missing_args", []string{"write"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checkGolden(t, tt.name, transcript(newTestFS(), tt.args...))
+		})
+	}
+}
+
+func TestWriteReadOnly(t *testing.T) {
+	fsys := newTestFS()
+	fsys.readOnly = true
+	checkGolden(t, "write_read_only", transcript(fsys, "write", "out.txt", "data"))
+}
+
+func TestWriteThenCat(t *testing.T) {
+	fsys := newTestFS()
+	if got := transcript(fsys, "write", "notes.txt", "first line"); !strings.HasPrefix(got, "exit: 0\n") {
+		t.Fatalf("write failed:\n%s", got)
+	}
+	data, err := fsys.ReadFile("notes.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "first line" {
+		t.Errorf("file content = %q, want %q", data, "first line")
+	}
+}
+
+func TestBusyboxDispatch(t *testing.T) {
+	tests := []struct {
+		arg0 string
+		args []string
+		want string
+	}{
+		{"cat", []string{"hello.txt"}, "Hello, WasmHub!\n"},
+		{"/usr/bin/echo.wasm", []string{"a", "b"}, "a b\n"},
+		{"printenv", []string{"LANG"}, "C.UTF-8\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.arg0, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			code := dispatch(newTestEnvironment(newTestFS(), &stdout, &stderr), append([]string{tt.arg0}, tt.args...))
+			if code != 0 {
+				t.Fatalf("exit code = %d, stderr: %s", code, stderr.String())
+			}
+			if stdout.String() != tt.want {
+				t.Errorf("stdout = %q, want %q", stdout.String(), tt.want)
+			}
+		})
+	}
+}
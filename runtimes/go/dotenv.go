@@ -0,0 +1,268 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// parseDotenv applies the assignments in a .env file to vars. The format
+// follows the common dotenv conventions:
+//
+//	# comment
+//	export KEY=value            # unquoted: trimmed, inline comments dropped
+//	KEY='literal $NOT_EXPANDED'  # single quotes: taken verbatim
+//	KEY="line\nbreak ${OTHER}"   # double quotes: escapes and expansion, may span lines
+//
+// $NAME, ${NAME} and ${NAME:-default} are expanded in unquoted and
+// double-quoted values against variables defined so far.
+func parseDotenv(src string, vars *envList) error {
+	p := &dotenvParser{src: src, line: 1, vars: vars}
+	for {
+		p.skipBlank()
+		if p.eof() {
+			return nil
+		}
+		line := p.line
+		if err := p.assignment(); err != nil {
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+	}
+}
+
+type dotenvParser struct {
+	src  string
+	pos  int
+	line int
+	vars *envList
+}
+
+func (p *dotenvParser) eof() bool { return p.pos >= len(p.src) }
+
+func (p *dotenvParser) peek() byte {
+	if p.eof() {
+		return 0
+	}
+	return p.src[p.pos]
+}
+
+func (p *dotenvParser) next() byte {
+	c := p.src[p.pos]
+	p.pos++
+	if c == '\n' {
+		p.line++
+	}
+	return c
+}
+
+// skipBlank skips whitespace, empty lines and comment lines.
+func (p *dotenvParser) skipBlank() {
+	for !p.eof() {
+		switch c := p.peek(); {
+		case c == ' ' || c == '\t' || c == '\r' || c == '\n':
+			p.next()
+		case c == '#':
+			p.skipLine()
+		default:
+			return
+		}
+	}
+}
+
+// skipLine advances to the end of the current line, leaving the newline.
+func (p *dotenvParser) skipLine() {
+	for !p.eof() && p.peek() != '\n' {
+		p.next()
+	}
+}
+
+func (p *dotenvParser) skipSpaces() {
+	for p.peek() == ' ' || p.peek() == '\t' {
+		p.next()
+	}
+}
+
+func (p *dotenvParser) assignment() error {
+	key := p.name()
+	if key == "export" && (p.peek() == ' ' || p.peek() == '\t') {
+		p.skipSpaces()
+		key = p.name()
+	}
+	if key == "" {
+		return fmt.Errorf("expected variable name")
+	}
+	p.skipSpaces()
+	if p.eof() || p.next() != '=' {
+		return fmt.Errorf("expected '=' after %s", key)
+	}
+	p.skipSpaces()
+
+	var value string
+	var err error
+	switch p.peek() {
+	case '\'':
+		value, err = p.singleQuoted()
+	case '"':
+		value, err = p.doubleQuoted()
+	default:
+		value, err = p.unquoted()
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", key, err)
+	}
+
+	if err := p.endOfLine(); err != nil {
+		return fmt.Errorf("%s: %w", key, err)
+	}
+	p.vars.set(key, value)
+	return nil
+}
+
+func (p *dotenvParser) name() string {
+	start := p.pos
+	for !p.eof() && isNameByte(p.peek(), p.pos == start) {
+		p.next()
+	}
+	return p.src[start:p.pos]
+}
+
+// refName reads the name in an unbraced $NAME reference. Unlike keys it
+// stops at '.', so $HOST.example.com expands HOST.
+func (p *dotenvParser) refName() string {
+	start := p.pos
+	for !p.eof() && p.peek() != '.' && isNameByte(p.peek(), p.pos == start) {
+		p.next()
+	}
+	return p.src[start:p.pos]
+}
+
+func isNameByte(c byte, first bool) bool {
+	switch {
+	case c == '_' || (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z'):
+		return true
+	case c >= '0' && c <= '9' || c == '.':
+		return !first
+	}
+	return false
+}
+
+// endOfLine accepts trailing whitespace and an optional comment after a
+// quoted value.
+func (p *dotenvParser) endOfLine() error {
+	p.skipSpaces()
+	switch c := p.peek(); c {
+	case 0, '\n', '\r':
+	case '#':
+		p.skipLine()
+	default:
+		return fmt.Errorf("unexpected %q after value", c)
+	}
+	return nil
+}
+
+func (p *dotenvParser) singleQuoted() (string, error) {
+	p.next()
+	start := p.pos
+	for !p.eof() {
+		if p.peek() == '\'' {
+			value := p.src[start:p.pos]
+			p.next()
+			return value, nil
+		}
+		p.next()
+	}
+	return "", fmt.Errorf("unterminated single-quoted value")
+}
+
+func (p *dotenvParser) doubleQuoted() (string, error) {
+	p.next()
+	var b strings.Builder
+	for !p.eof() {
+		switch c := p.next(); c {
+		case '"':
+			return b.String(), nil
+		case '\\':
+			if p.eof() {
+				break
+			}
+			switch esc := p.next(); esc {
+			case 'n':
+				b.WriteByte('\n')
+			case 'r':
+				b.WriteByte('\r')
+			case 't':
+				b.WriteByte('\t')
+			case '"', '\\', '$':
+				b.WriteByte(esc)
+			default:
+				b.WriteByte('\\')
+				b.WriteByte(esc)
+			}
+		case '$':
+			if err := p.expand(&b); err != nil {
+				return "", err
+			}
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return "", fmt.Errorf("unterminated double-quoted value")
+}
+
+// unquoted reads to the end of the line, dropping an inline comment that
+// follows whitespace and trimming trailing whitespace.
+func (p *dotenvParser) unquoted() (string, error) {
+	var b strings.Builder
+	for !p.eof() {
+		c := p.peek()
+		if c == '\n' || c == '\r' {
+			break
+		}
+		if c == '#' && b.Len() > 0 && strings.ContainsAny(b.String()[b.Len()-1:], " \t") {
+			p.skipLine()
+			break
+		}
+		p.next()
+		if c == '$' {
+			if err := p.expand(&b); err != nil {
+				return "", err
+			}
+			continue
+		}
+		b.WriteByte(c)
+	}
+	return strings.TrimRight(b.String(), " \t"), nil
+}
+
+// expand handles the text after a '$'. A '$' that does not start a
+// reference is kept as is; a ${ reference must be closed on the same line.
+func (p *dotenvParser) expand(b *strings.Builder) error {
+	if p.peek() != '{' {
+		name := p.refName()
+		if name == "" {
+			b.WriteByte('$')
+			return nil
+		}
+		v, _ := p.vars.get(name)
+		b.WriteString(v)
+		return nil
+	}
+
+	p.next()
+	rest, _, _ := strings.Cut(p.src[p.pos:], "\n")
+	end := strings.IndexByte(rest, '}')
+	if end < 0 {
+		return fmt.Errorf("unterminated ${ reference")
+	}
+	ref := p.src[p.pos : p.pos+end]
+	for range end + 1 {
+		p.next()
+	}
+
+	name, def, hasDefault := strings.Cut(ref, ":-")
+	v, ok := p.vars.get(name)
+	if hasDefault && (!ok || v == "") {
+		v = def
+	}
+	b.WriteString(v)
+	return nil
+}
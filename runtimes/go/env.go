@@ -0,0 +1,228 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+)
+
+// sensitiveKeys are the globs (matched against the upper-cased key) whose
+// values env masks unless --reveal is given.
+var sensitiveKeys = []string{"*TOKEN*", "*SECRET*", "*PASSWORD*"}
+
+const maskedValue = "********"
+
+// listFlag collects every value of a repeatable string flag.
+type listFlag []string
+
+func (l *listFlag) String() string     { return strings.Join(*l, ",") }
+func (l *listFlag) Set(v string) error { *l = append(*l, v); return nil }
+
+func registerEnv(r *registry) {
+	cmd := r.register(&command{
+		name:    "env",
+		args:    "[NAME=VALUE...] [command [args...]]",
+		maxArgs: -1,
+		summary: "Print environment variables or run a command with a modified environment",
+	})
+
+	var unset, files listFlag
+	ignore := cmd.flags.Bool("i", false, "start with an empty environment")
+	cmd.flags.Var(&unset, "u", "remove `NAME` from the environment (repeatable)")
+	cmd.flags.Var(&files, "file", "load variables from a .env `FILE` (repeatable)")
+	prefix := cmd.flags.String("prefix", "", "only print variables whose name starts with `PREFIX`")
+	glob := cmd.flags.String("glob", "", "only print variables whose name matches `PATTERN`")
+	asJSON := cmd.flags.Bool("json", false, "print variables as a JSON object")
+	reveal := cmd.flags.Bool("reveal", false, "print values of secret-looking variables unmasked")
+
+	cmd.run = func(e *environment, args []string) int {
+		// Flags and NAME=VALUE assignments may be interleaved; the first
+		// argument that is neither names the command to run.
+		var assigns []string
+		for {
+			for len(args) > 0 && isAssignment(args[0]) {
+				assigns = append(assigns, args[0])
+				args = args[1:]
+			}
+			if len(args) == 0 || !strings.HasPrefix(args[0], "-") {
+				break
+			}
+			if err := cmd.flags.Parse(args); err != nil {
+				return cmd.usageError(e, fmt.Sprintf("env: %v", err))
+			}
+			args = cmd.flags.Args()
+		}
+
+		vars := newEnvList(e.environ)
+		if *ignore {
+			vars = newEnvList(nil)
+		}
+		for _, name := range unset {
+			vars.unset(name)
+		}
+		for _, file := range files {
+			data, err := e.fs.ReadFile(file)
+			if err != nil {
+				fmt.Fprintf(e.stderr, "Error reading %s: %v\n", file, err)
+				return 1
+			}
+			if err := parseDotenv(string(data), vars); err != nil {
+				fmt.Fprintf(e.stderr, "Error parsing %s: %v\n", file, err)
+				return 1
+			}
+		}
+		for _, kv := range assigns {
+			k, v, _ := strings.Cut(kv, "=")
+			vars.set(k, v)
+		}
+
+		if len(args) > 0 {
+			// A fresh registry keeps the flags of a nested env (or any
+			// other command) independent of this invocation's.
+			target := newRegistry().lookup(args[0])
+			if target == nil {
+				fmt.Fprintf(e.stderr, "env: unknown command: %s\n", args[0])
+				return 127
+			}
+			child := *e
+			child.environ = vars.environ()
+			return target.execute(&child, args[1:])
+		}
+
+		if *glob != "" {
+			if _, err := path.Match(*glob, ""); err != nil {
+				fmt.Fprintf(e.stderr, "Error: invalid --glob pattern %q: %v\n", *glob, err)
+				return 1
+			}
+		}
+		shown := newEnvList(nil)
+		for _, k := range vars.keys {
+			if !strings.HasPrefix(k, *prefix) {
+				continue
+			}
+			if ok, _ := path.Match(*glob, k); *glob != "" && !ok {
+				continue
+			}
+			v := vars.values[k]
+			if !*reveal && isSensitiveKey(k) {
+				v = maskedValue
+			}
+			shown.set(k, v)
+		}
+
+		if *asJSON {
+			out, err := json.MarshalIndent(shown.values, "", "  ")
+			if err != nil {
+				fmt.Fprintf(e.stderr, "Error encoding JSON: %v\n", err)
+				return 1
+			}
+			fmt.Fprintln(e.stdout, string(out))
+			return 0
+		}
+		for _, kv := range shown.environ() {
+			fmt.Fprintln(e.stdout, kv)
+		}
+		return 0
+	}
+}
+
+func registerPrintenv(r *registry) {
+	cmd := r.register(&command{
+		name:    "printenv",
+		args:    "[NAME...]",
+		maxArgs: -1,
+		summary: "Print the values of the named environment variables, or all of them",
+	})
+	reveal := cmd.flags.Bool("reveal", false, "print values of secret-looking variables unmasked")
+
+	cmd.run = func(e *environment, args []string) int {
+		if len(args) == 0 {
+			var envArgs []string
+			if *reveal {
+				envArgs = []string{"--reveal"}
+			}
+			return newRegistry().lookup("env").execute(e, envArgs)
+		}
+		// Like POSIX printenv, a missing variable prints nothing and makes
+		// the exit status 1.
+		vars := newEnvList(e.environ)
+		code := 0
+		for _, name := range args {
+			v, ok := vars.get(name)
+			if !ok {
+				code = 1
+				continue
+			}
+			if !*reveal && isSensitiveKey(name) {
+				v = maskedValue
+			}
+			fmt.Fprintln(e.stdout, v)
+		}
+		return code
+	}
+}
+
+func isAssignment(arg string) bool {
+	k, _, ok := strings.Cut(arg, "=")
+	return ok && k != "" && !strings.HasPrefix(k, "-")
+}
+
+func isSensitiveKey(key string) bool {
+	upper := strings.ToUpper(key)
+	for _, pattern := range sensitiveKeys {
+		if ok, _ := path.Match(pattern, upper); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// envList is an environment that remembers the order variables were
+// first defined in, like os.Environ does.
+type envList struct {
+	keys   []string
+	values map[string]string
+}
+
+func newEnvList(environ []string) *envList {
+	l := &envList{values: make(map[string]string)}
+	for _, kv := range environ {
+		k, v, _ := strings.Cut(kv, "=")
+		l.set(k, v)
+	}
+	return l
+}
+
+func (l *envList) get(key string) (string, bool) {
+	v, ok := l.values[key]
+	return v, ok
+}
+
+func (l *envList) set(key, value string) {
+	if _, ok := l.values[key]; !ok {
+		l.keys = append(l.keys, key)
+	}
+	l.values[key] = value
+}
+
+func (l *envList) unset(key string) {
+	if _, ok := l.values[key]; !ok {
+		return
+	}
+	delete(l.values, key)
+	for i, k := range l.keys {
+		if k == key {
+			l.keys = append(l.keys[:i], l.keys[i+1:]...)
+			break
+		}
+	}
+}
+
+func (l *envList) environ() []string {
+	environ := make([]string, len(l.keys))
+	for i, k := range l.keys {
+		environ[i] = k + "=" + l.values[k]
+	}
+	return environ
+}
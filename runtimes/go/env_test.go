@@ -0,0 +1,105 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestParseDotenv(t *testing.T) {
+	src := strings.Join([]string{
+		"# database settings",
+		"",
+		"DB_HOST=localhost",
+		"export DB_PORT = 5432",
+		"DB_URL=postgres://${DB_HOST}:$DB_PORT/app  # inline comment",
+		"LITERAL='no $DB_HOST expansion # here'",
+		`QUOTED="tab\there \"quoted\" \$HOME"`,
+		`MULTI="first`,
+		`second"`,
+		"DEFAULTED=${MISSING:-fallback}",
+		"HASH=a#b",
+		"EMPTY=",
+		"DOLLAR=cost $ 5",
+		"SITE=$DB_HOST.example.com",
+		`QUOTED_SITE="https://$DB_HOST.example.com/"`,
+	}, "\n")
+
+	vars := newEnvList([]string{"HOME=/home/wasm"})
+	if err := parseDotenv(src, vars); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		"HOME=/home/wasm",
+		"DB_HOST=localhost",
+		"DB_PORT=5432",
+		"DB_URL=postgres://localhost:5432/app",
+		"LITERAL=no $DB_HOST expansion # here",
+		`QUOTED=tab	here "quoted" $HOME`,
+		"MULTI=first\nsecond",
+		"DEFAULTED=fallback",
+		"HASH=a#b",
+		"EMPTY=",
+		"DOLLAR=cost $ 5",
+		"SITE=localhost.example.com",
+		"QUOTED_SITE=https://localhost.example.com/",
+	}
+	if got := vars.environ(); !reflect.DeepEqual(got, want) {
+		t.Errorf("environ mismatch\n got: %q\nwant: %q", got, want)
+	}
+}
+
+func TestParseDotenvErrors(t *testing.T) {
+	tests := []struct {
+		src, want string
+	}{
+		{"=value", "line 1: expected variable name"},
+		{"OK=1\nNO_EQUALS", "line 2: expected '=' after NO_EQUALS"},
+		{"KEY='open", "line 1: KEY: unterminated single-quoted value"},
+		{"KEY=\"open\n", "line 1: KEY: unterminated double-quoted value"},
+		{"KEY=\"a\" b", "line 1: KEY: unexpected 'b' after value"},
+		{"KEY=\"${OPEN\"", "line 1: KEY: unterminated ${ reference"},
+		{"KEY=\"${OPEN\n}\"", "line 1: KEY: unterminated ${ reference"},
+		{"A=${B\nC=1}", "line 1: A: unterminated ${ reference"},
+	}
+	for _, tt := range tests {
+		err := parseDotenv(tt.src, newEnvList(nil))
+		if err == nil || err.Error() != tt.want {
+			t.Errorf("parseDotenv(%q) error = %v, want %q", tt.src, err, tt.want)
+		}
+	}
+}
+
+func TestEnvGolden(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{"env_masked", []string{"env"}},
+		{"env_reveal", []string{"env", "--reveal"}},
+		{"env_json", []string{"env", "--json"}},
+		{"env_prefix", []string{"env", "--prefix", "PA"}},
+		{"env_glob", []string{"env", "--glob", "*A*"}},
+		{"env_bad_glob", []string{"env", "--glob", "["}},
+		{"env_run", []string{"env", "-i", "FOO=bar", "-u", "HOME", "env"}},
+		{"env_run_unset", []string{"env", "-u", "HOME", "-u", "PATH", "GREETING=hi", "env", "--prefix", "G"}},
+		{"env_file", []string{"env", "-i", "--file", "app.env", "APP_MODE=test", "env", "--reveal"}},
+		{"env_file_missing", []string{"env", "--file", "missing.env"}},
+		{"env_file_invalid", []string{"env", "--file", "bad.env"}},
+		{"env_unknown_flag", []string{"env", "A=1", "--bogus"}},
+		{"printenv_all", []string{"printenv"}},
+		{"printenv_names", []string{"printenv", "HOME", "GITHUB_TOKEN", "LANG"}},
+		{"printenv_reveal", []string{"printenv", "--reveal", "GITHUB_TOKEN"}},
+		{"printenv_missing", []string{"printenv", "HOME", "NOPE", "LANG"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fsys := newTestFS()
+			fsys.files["app.env"] = &fstest.MapFile{Data: []byte("APP_MODE=dev\nAPI_SECRET='s3cr3t'\nAPP_URL=http://${APP_HOST:-localhost}:8080\n")}
+			fsys.files["bad.env"] = &fstest.MapFile{Data: []byte("GOOD=1\nBAD='oops\n")}
+			checkGolden(t, tt.name, transcript(fsys, tt.args...))
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"crypto/rand"
+	"io"
+	"io/fs"
+	"os"
+	"time"
+
+	"github.com/anistark/wasmhub/runtimes/go/buildinfo"
+)
+
+// environment is everything a command may touch besides its arguments.
+// main wires it to the real process; tests substitute buffers and an
+// in-memory filesystem.
+type environment struct {
+	stdin   io.Reader
+	stdout  io.Writer
+	stderr  io.Writer
+	fs      fileSystem
+	environ []string
+	now     func() time.Time
+	sleep   func(time.Duration)
+	rand    io.Reader
+	build   buildinfo.Info
+}
+
+func newOSEnvironment() *environment {
+	return &environment{
+		stdin:   os.Stdin,
+		stdout:  os.Stdout,
+		stderr:  os.Stderr,
+		fs:      osFS{},
+		environ: os.Environ(),
+		now:     time.Now,
+		// TinyGo implements time.Sleep with a poll_oneoff clock
+		// subscription on WASI, so this never busy-waits.
+		sleep: time.Sleep,
+		// crypto/rand reads from WASI random_get.
+		rand:  rand.Reader,
+		build: buildinfo.Current(),
+	}
+}
+
+// fileSystem is the subset of file operations commands need. Paths are
+// passed through as given on the command line, like the os package does.
+type fileSystem interface {
+	ReadFile(name string) ([]byte, error)
+	ReadDir(name string) ([]fs.DirEntry, error)
+	Stat(name string) (fs.FileInfo, error)
+	WriteFile(name string, data []byte, perm fs.FileMode) error
+}
+
+// osFS is the fileSystem backed by the WASI preopened directories.
+type osFS struct{}
+
+func (osFS) ReadFile(name string) ([]byte, error) {
+	return os.ReadFile(name)
+}
+
+func (osFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	return os.ReadDir(name)
+}
+
+func (osFS) Stat(name string) (fs.FileInfo, error) {
+	return os.Stat(name)
+}
+
+func (osFS) WriteFile(name string, data []byte, perm fs.FileMode) error {
+	return os.WriteFile(name, data, perm)
+}
@@ -0,0 +1,3 @@
+module github.com/anistark/wasmhub/runtimes/go
+
+go 1.23
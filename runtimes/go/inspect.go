@@ -0,0 +1,300 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/anistark/wasmhub/runtimes/go/buildinfo"
+	"github.com/anistark/wasmhub/runtimes/go/wasm"
+)
+
+func registerWasmTools(r *registry) {
+	cmd := r.register(&command{
+		name:         "inspect",
+		args:         "<file.wasm>",
+		minArgs:      1,
+		maxArgs:      1,
+		summary:      "Validate a WebAssembly module and list its sections, imports and exports",
+		interspersed: true,
+	})
+	asJSON := cmd.flags.Bool("json", false, "print the module summary as JSON")
+	cmd.run = func(e *environment, args []string) int {
+		return inspectModule(e, args[0], *asJSON)
+	}
+
+	tool := r.register(&command{
+		name:         "wasm",
+		args:         "<inspect|dis> <file.wasm>",
+		minArgs:      2,
+		maxArgs:      2,
+		summary:      "Inspect a WebAssembly module or disassemble it to text format",
+		interspersed: true,
+	})
+	toolJSON := tool.flags.Bool("json", false, "inspect: print the module summary as JSON")
+	toolFunc := tool.flags.String("func", "", "dis: only disassemble the function with this `NAME`, export name or index")
+	tool.run = func(e *environment, args []string) int {
+		switch sub, file := args[0], args[1]; sub {
+		case "inspect":
+			if *toolFunc != "" {
+				return tool.usageError(e, "--func applies to wasm dis only")
+			}
+			return inspectModule(e, file, *toolJSON)
+		case "dis":
+			if *toolJSON {
+				return tool.usageError(e, "--json applies to wasm inspect only")
+			}
+			return disassemble(e, file, *toolFunc)
+		default:
+			return tool.usageError(e, fmt.Sprintf("unknown wasm subcommand: %s", sub))
+		}
+	}
+}
+
+// inspectReport is the --json form of inspect's output.
+type inspectReport struct {
+	File     string          `json:"file"`
+	Size     int             `json:"size"`
+	Version  uint32          `json:"version"`
+	Sections []sectionReport `json:"sections"`
+	Types    []string        `json:"types"`
+	Imports  []importReport  `json:"imports"`
+	Exports  []exportReport  `json:"exports"`
+	Memories []memoryReport  `json:"memories"`
+	Customs  []customReport  `json:"custom_sections"`
+	Funcs    int             `json:"functions"`
+	Features []string        `json:"features"`
+	Build    *buildinfo.Info `json:"build_info,omitempty"`
+}
+
+type sectionReport struct {
+	ID     byte   `json:"id"`
+	Name   string `json:"name"`
+	Offset int    `json:"offset"`
+	Size   int    `json:"size"`
+}
+
+type importReport struct {
+	Module    string `json:"module"`
+	Name      string `json:"name"`
+	Kind      string `json:"kind"`
+	Signature string `json:"signature"`
+}
+
+type exportReport struct {
+	Name  string `json:"name"`
+	Kind  string `json:"kind"`
+	Index uint32 `json:"index"`
+}
+
+type memoryReport struct {
+	Imported bool    `json:"imported"`
+	Min      uint64  `json:"min"`
+	Max      *uint64 `json:"max"`
+	Shared   bool    `json:"shared"`
+	Memory64 bool    `json:"memory64"`
+}
+
+type customReport struct {
+	Name string `json:"name"`
+	Size int    `json:"size"`
+}
+
+// readModule reads and parses a wasm file, reporting failures to stderr.
+func readModule(e *environment, file string) (*wasm.Module, []byte, bool) {
+	data, err := e.fs.ReadFile(file)
+	if err != nil {
+		fmt.Fprintf(e.stderr, "Error reading %s: %v\n", file, err)
+		return nil, nil, false
+	}
+	m, err := wasm.Parse(data)
+	if err != nil {
+		fmt.Fprintf(e.stderr, "Error: %s is not a valid WebAssembly module: %v\n", file, err)
+		return nil, nil, false
+	}
+	return m, data, true
+}
+
+func inspectModule(e *environment, file string, asJSON bool) int {
+	m, data, ok := readModule(e, file)
+	if !ok {
+		return 1
+	}
+	report := newInspectReport(file, len(data), m)
+
+	if asJSON {
+		enc := json.NewEncoder(e.stdout)
+		enc.SetEscapeHTML(false) // keep "->" in signatures readable
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(report); err != nil {
+			fmt.Fprintf(e.stderr, "Error encoding JSON: %v\n", err)
+			return 1
+		}
+		return 0
+	}
+	printInspectReport(e, report)
+	return 0
+}
+
+// disassemble writes the module, or only the function fn, as WAT text.
+func disassemble(e *environment, file, fn string) int {
+	m, _, ok := readModule(e, file)
+	if !ok {
+		return 1
+	}
+	var funcs []uint32
+	if fn != "" {
+		idx, ok := m.FuncIndex(fn)
+		if !ok {
+			fmt.Fprintf(e.stderr, "Error: no function %s in %s\n", fn, file)
+			return 1
+		}
+		funcs = append(funcs, idx)
+	}
+	if err := wasm.WriteWAT(e.stdout, m, funcs...); err != nil {
+		fmt.Fprintf(e.stderr, "Error disassembling %s: %v\n", file, err)
+		return 1
+	}
+	return 0
+}
+
+func newInspectReport(file string, size int, m *wasm.Module) inspectReport {
+	report := inspectReport{
+		File:     file,
+		Size:     size,
+		Version:  m.Version,
+		Sections: []sectionReport{},
+		Types:    []string{},
+		Imports:  []importReport{},
+		Exports:  []exportReport{},
+		Memories: []memoryReport{},
+		Customs:  []customReport{},
+		Funcs:    m.ImportedFuncs() + len(m.Functions),
+		Features: wasm.Features(m),
+	}
+	for _, s := range m.Sections {
+		report.Sections = append(report.Sections, sectionReport{ID: s.ID, Name: s.Name, Offset: s.Offset, Size: s.Size})
+	}
+	for _, t := range m.Types {
+		report.Types = append(report.Types, t.String())
+	}
+	for _, imp := range m.Imports {
+		report.Imports = append(report.Imports, importReport{
+			Module:    imp.Module,
+			Name:      imp.Name,
+			Kind:      imp.Kind.String(),
+			Signature: importSignature(m, imp),
+		})
+		if imp.Kind == wasm.KindMemory {
+			report.Memories = append(report.Memories, newMemoryReport(imp.Memory, true))
+		}
+	}
+	for _, mem := range m.Memories {
+		report.Memories = append(report.Memories, newMemoryReport(mem, false))
+	}
+	for _, exp := range m.Exports {
+		report.Exports = append(report.Exports, exportReport{Name: exp.Name, Kind: exp.Kind.String(), Index: exp.Index})
+	}
+	for _, c := range m.Customs {
+		report.Customs = append(report.Customs, customReport{Name: c.Name, Size: len(c.Data)})
+	}
+	// A malformed build info section is still listed among the custom
+	// sections; it is just not decoded.
+	if info, ok, err := buildinfo.FromModule(m); ok && err == nil {
+		report.Build = &info
+	}
+	return report
+}
+
+func newMemoryReport(l wasm.Limits, imported bool) memoryReport {
+	return memoryReport{Imported: imported, Min: l.Min, Max: l.Max, Shared: l.Shared, Memory64: l.Is64}
+}
+
+// importSignature describes what an import expects: a function signature,
+// table or memory limits, or a global's type.
+func importSignature(m *wasm.Module, imp wasm.Import) string {
+	switch imp.Kind {
+	case wasm.KindFunc, wasm.KindTag:
+		if int(imp.Type) < len(m.Types) {
+			return m.Types[imp.Type].String()
+		}
+		return "type " + strconv.FormatUint(uint64(imp.Type), 10) + " (out of range)"
+	case wasm.KindTable:
+		return imp.Table.Elem.String() + ", " + imp.Table.Limits.String()
+	case wasm.KindMemory:
+		return imp.Memory.String()
+	case wasm.KindGlobal:
+		return imp.Global.String()
+	}
+	return ""
+}
+
+func printInspectReport(e *environment, r inspectReport) {
+	fmt.Fprintf(e.stdout, "%s: WebAssembly version %d, %d bytes, %d functions\n", r.File, r.Version, r.Size, r.Funcs)
+
+	fmt.Fprintf(e.stdout, "\nSections (%d):\n", len(r.Sections))
+	width := 0
+	for _, s := range r.Sections {
+		width = max(width, len(s.Name))
+	}
+	for _, s := range r.Sections {
+		fmt.Fprintf(e.stdout, "  %-*s  offset 0x%06x  %8d bytes\n", width, s.Name, s.Offset, s.Size)
+	}
+
+	if len(r.Types) > 0 {
+		fmt.Fprintf(e.stdout, "\nTypes (%d):\n", len(r.Types))
+		for i, t := range r.Types {
+			fmt.Fprintf(e.stdout, "  %d: %s\n", i, t)
+		}
+	}
+	if len(r.Imports) > 0 {
+		fmt.Fprintf(e.stdout, "\nImports (%d):\n", len(r.Imports))
+		for _, imp := range r.Imports {
+			fmt.Fprintf(e.stdout, "  %-6s %s.%s %s\n", imp.Kind, imp.Module, imp.Name, imp.Signature)
+		}
+		if len(r.Features) > 0 {
+			fmt.Fprintf(e.stdout, "\nWASI features: %s\n", strings.Join(r.Features, ", "))
+		}
+	}
+	if len(r.Exports) > 0 {
+		fmt.Fprintf(e.stdout, "\nExports (%d):\n", len(r.Exports))
+		for _, exp := range r.Exports {
+			fmt.Fprintf(e.stdout, "  %-6s %s -> %s %d\n", exp.Kind, exp.Name, exp.Kind, exp.Index)
+		}
+	}
+	if len(r.Memories) > 0 {
+		fmt.Fprintf(e.stdout, "\nMemories (%d, in 64 KiB pages):\n", len(r.Memories))
+		for i, mem := range r.Memories {
+			limits := wasm.Limits{Min: mem.Min, Max: mem.Max, Shared: mem.Shared, Is64: mem.Memory64}
+			suffix := ""
+			if mem.Imported {
+				suffix = " (imported)"
+			}
+			fmt.Fprintf(e.stdout, "  %d: %s%s\n", i, limits, suffix)
+		}
+	}
+	if len(r.Customs) > 0 {
+		fmt.Fprintf(e.stdout, "\nCustom sections (%d):\n", len(r.Customs))
+		for _, c := range r.Customs {
+			fmt.Fprintf(e.stdout, "  %s: %d bytes\n", c.Name, c.Size)
+		}
+	}
+	if b := r.Build; b != nil {
+		fmt.Fprintln(e.stdout, "\nBuild info:")
+		for _, field := range [][2]string{
+			{"version", b.Version},
+			{"go", b.GoVersion},
+			{"tinygo", b.TinyGo},
+			{"llvm", b.LLVM},
+			{"target", b.Target},
+			{"commit", b.Commit},
+			{"date", b.Date},
+			{"optimization", b.Optimization},
+		} {
+			if field[1] != "" {
+				fmt.Fprintf(e.stdout, "  %-12s  %s\n", field[0], field[1])
+			}
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/anistark/wasmhub/runtimes/go/buildinfo"
+)
+
+// helloWasm imports fd_write, defines a memory and a named _start
+// function, and exports both.
+const helloWasm = "\x00asm\x01\x00\x00\x00" +
+	"\x01\f\x02`\x04\x7f\x7f\x7f\x7f\x01\x7f`\x00\x00" +
+	"\x02#\x01\x16wasi_snapshot_preview1\bfd_write\x00\x00" +
+	"\x03\x02\x01\x01" +
+	"\x05\x03\x01\x00\x02" +
+	"\a\x13\x02\x06_start\x00\x01\x06memory\x02\x00" +
+	"\n\x04\x01\x02\x00\v" +
+	"\x00\x10\x04name\x01\t\x01\x01\x06_start"
+
+func TestWasmToolsGolden(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{"inspect", []string{"inspect", "hello.wasm"}},
+		{"inspect_json", []string{"inspect", "--json", "hello.wasm"}},
+		{"inspect_json_after_file", []string{"inspect", "hello.wasm", "--json"}},
+		{"inspect_extra_file", []string{"inspect", "hello.wasm", "--json", "built.wasm"}},
+		{"inspect_dashdash", []string{"inspect", "--", "--json"}},
+		{"wasm_dis_dashdash", []string{"wasm", "dis", "--", "hello.wasm", "--func", "_start"}},
+		{"inspect_not_wasm", []string{"inspect", "hello.txt"}},
+		{"inspect_truncated", []string{"inspect", "truncated.wasm"}},
+		{"inspect_not_found", []string{"inspect", "missing.wasm"}},
+		{"inspect_buildinfo", []string{"inspect", "built.wasm"}},
+		{"wasm_inspect", []string{"wasm", "inspect", "hello.wasm"}},
+		{"wasm_inspect_json_after_file", []string{"wasm", "inspect", "hello.wasm", "--json"}},
+		{"wasm_dis", []string{"wasm", "dis", "hello.wasm"}},
+		{"wasm_dis_func", []string{"wasm", "dis", "--func", "_start", "hello.wasm"}},
+		{"wasm_dis_func_missing", []string{"wasm", "dis", "hello.wasm", "--func", "main"}},
+		{"wasm_dis_json", []string{"wasm", "dis", "--json", "hello.wasm"}},
+		{"wasm_unknown_subcommand", []string{"wasm", "strip", "hello.wasm"}},
+	}
+	built, err := buildinfo.Embed([]byte(helloWasm), buildinfo.Info{Version: "1.23", Target: "wasip1", Commit: "0123456789ab"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fsys := newTestFS()
+			fsys.files["hello.wasm"] = &fstest.MapFile{Data: []byte(helloWasm)}
+			fsys.files["truncated.wasm"] = &fstest.MapFile{Data: []byte(helloWasm[:40])}
+			fsys.files["built.wasm"] = &fstest.MapFile{Data: built}
+			checkGolden(t, tt.name, transcript(fsys, tt.args...))
+		})
+	}
+}
@@ -1,131 +1,109 @@
 package main
 
 import (
+	"errors"
+	"flag"
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
+	"strings"
 )
 
-func main() {
-	args := os.Args
-	if len(args) < 2 {
-		printUsage()
-		return
-	}
+const progName = "go-runtime"
 
-	switch args[1] {
-	case "version":
-		printVersion()
-	case "eval":
-		if len(args) < 3 {
-			fmt.Fprintln(os.Stderr, "Error: eval requires an expression")
-			os.Exit(1)
-		}
-		eval(args[2])
-	case "env":
-		printEnv()
-	case "echo":
-		if len(args) > 2 {
-			for i, arg := range args[2:] {
-				if i > 0 {
-					fmt.Print(" ")
-				}
-				fmt.Print(arg)
-			}
-		}
-		fmt.Println()
-	case "cat":
-		if len(args) < 3 {
-			fmt.Fprintln(os.Stderr, "Error: cat requires a filename")
-			os.Exit(1)
-		}
-		catFile(args[2])
-	case "ls":
-		path := "."
-		if len(args) > 2 {
-			path = args[2]
-		}
-		listDir(path)
-	case "write":
-		if len(args) < 4 {
-			fmt.Fprintln(os.Stderr, "Error: write requires filename and content")
-			os.Exit(1)
-		}
-		writeFile(args[2], args[3])
-	default:
-		fmt.Fprintf(os.Stderr, "Unknown command: %s\n", args[1])
-		printUsage()
-		os.Exit(1)
-	}
+func main() {
+	os.Exit(dispatch(newOSEnvironment(), os.Args))
 }
 
-func printUsage() {
-	fmt.Println("WasmHub Go Runtime")
-	fmt.Println()
-	fmt.Println("Usage: go-runtime <command> [args...]")
-	fmt.Println()
-	fmt.Println("Commands:")
-	fmt.Println("  version      Print runtime version info")
-	fmt.Println("  eval <expr>  Evaluate a simple expression")
-	fmt.Println("  env          Print environment variables")
-	fmt.Println("  echo [args]  Print arguments to stdout")
-	fmt.Println("  cat <file>   Print file contents")
-	fmt.Println("  ls [path]    List directory contents")
-	fmt.Println("  write <file> <content>  Write content to file")
+// globalOptions are the flags accepted before the command name.
+type globalOptions struct {
+	version bool
+	overlay overlayFlag
+	mounts  mountFlag
 }
 
-func printVersion() {
-	fmt.Println("WasmHub Go Runtime")
-	fmt.Println("Go Version: 1.23 (TinyGo)")
-	fmt.Println("Target: WASI Preview 1")
-	fmt.Println("Features: filesystem, env, args, stdio")
+func (o *globalOptions) flagSet() *flag.FlagSet {
+	fs := flag.NewFlagSet(progName, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	fs.BoolVar(&o.version, "version", false, "print runtime version info and exit")
+	fs.Var(&o.mounts, "mount", "mount a .zip, .tar or .tar.gz archive read-only at `ARCHIVE:PATH` (repeatable)")
+	fs.Var(&o.overlay, "overlay", "send all writes to an in-memory layer; --overlay=STATE keeps the layer in a tar file between runs")
+	return fs
 }
 
-func eval(expr string) {
-	fmt.Printf("Evaluating: %s\n", expr)
-	fmt.Println("Note: Full eval requires a Go interpreter")
-	fmt.Printf("Expression length: %d characters\n", len(expr))
-}
+// dispatch resolves the command named by args and runs it, returning the
+// process exit code.
+func dispatch(e *environment, args []string) int {
+	r := newRegistry()
 
-func printEnv() {
-	for _, env := range os.Environ() {
-		fmt.Println(env)
+	// Busybox-style dispatch: when the binary is invoked as `ls`, `cat.wasm`
+	// and so on, argv[0] names the command and every argument belongs to it.
+	if len(args) > 0 {
+		if cmd := r.lookup(invokedName(args[0])); cmd != nil {
+			return cmd.execute(e, args[1:])
+		}
 	}
-}
 
-func catFile(path string) {
-	data, err := os.ReadFile(path)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error reading %s: %v\n", path, err)
-		os.Exit(1)
+	var opts globalOptions
+	r.globals = opts.flagSet()
+	if len(args) > 1 {
+		if err := r.globals.Parse(args[1:]); err != nil {
+			if errors.Is(err, flag.ErrHelp) {
+				r.printUsage(e.stdout)
+				return 0
+			}
+			fmt.Fprintf(e.stderr, "Error: %v\n", err)
+			fmt.Fprintf(e.stderr, "Run '%s help' for usage.\n", progName)
+			return 1
+		}
 	}
-	fmt.Print(string(data))
-}
 
-func listDir(path string) {
-	entries, err := os.ReadDir(path)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error reading directory %s: %v\n", path, err)
-		os.Exit(1)
+	rest := r.globals.Args()
+	if opts.version {
+		rest = []string{"version"}
 	}
-	for _, entry := range entries {
-		info, err := entry.Info()
-		if err != nil {
-			fmt.Println(entry.Name())
-			continue
+	if len(rest) == 0 {
+		r.printUsage(e.stdout)
+		return 0
+	}
+
+	cmd := r.lookup(rest[0])
+	if cmd == nil {
+		fmt.Fprintf(e.stderr, "Unknown command: %s\n", rest[0])
+		r.printUsage(e.stderr)
+		return 1
+	}
+
+	if len(opts.mounts) > 0 {
+		if err := mountArchives(e, opts.mounts); err != nil {
+			fmt.Fprintf(e.stderr, "Error mounting %v\n", err)
+			return 1
 		}
-		typeChar := "-"
-		if entry.IsDir() {
-			typeChar = "d"
+	}
+
+	var ov *overlayFS
+	if opts.overlay.enabled {
+		var err error
+		if ov, err = mountOverlay(e, opts.overlay.state); err != nil {
+			fmt.Fprintf(e.stderr, "Error loading overlay: %v\n", err)
+			return 1
 		}
-		fmt.Printf("%s %8d %s\n", typeChar, info.Size(), entry.Name())
 	}
-}
 
-func writeFile(path, content string) {
-	err := os.WriteFile(path, []byte(content), 0644)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error writing %s: %v\n", path, err)
-		os.Exit(1)
+	code := cmd.execute(e, rest[1:])
+
+	if ov != nil {
+		if err := ov.save(); err != nil {
+			fmt.Fprintf(e.stderr, "Error saving overlay: %v\n", err)
+			return 1
+		}
 	}
-	fmt.Printf("Wrote %d bytes to %s\n", len(content), path)
+	return code
+}
+
+// invokedName strips the directory and any .wasm extension from argv[0].
+func invokedName(arg0 string) string {
+	name := filepath.Base(arg0)
+	return strings.TrimSuffix(name, ".wasm")
 }
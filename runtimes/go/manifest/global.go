@@ -0,0 +1,72 @@
+package manifest
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// Global is the top-level manifest.json listing every language, as read by
+// the wasmhub crate's GlobalManifest.
+type Global struct {
+	Version   string                  `json:"version"`
+	Languages map[string]LanguageInfo `json:"languages"`
+}
+
+// LanguageInfo summarizes one language's runtime manifest.
+type LanguageInfo struct {
+	Latest   string   `json:"latest"`
+	LTS      string   `json:"lts,omitempty"`
+	Versions []string `json:"versions"`
+	Source   string   `json:"source"`
+	License  string   `json:"license"`
+}
+
+// ParseGlobal decodes a global manifest.
+func ParseGlobal(data []byte) (*Global, error) {
+	var g Global
+	if err := json.Unmarshal(data, &g); err != nil {
+		return nil, fmt.Errorf("invalid global manifest: %w", err)
+	}
+	return &g, nil
+}
+
+// Marshal encodes g in the layout used by the committed manifests.
+func (g *Global) Marshal() ([]byte, error) {
+	data, err := json.MarshalIndent(g, "", "    ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// SetLanguage records the versions of m in g, keeping the source, license
+// and LTS already listed for the language.
+func (g *Global) SetLanguage(m *Runtime, source, license string) {
+	if g.Languages == nil {
+		g.Languages = make(map[string]LanguageInfo)
+	}
+	info, ok := g.Languages[m.Language]
+	if !ok {
+		info = LanguageInfo{Source: source, License: license}
+	}
+	info.Latest = m.Latest
+	info.Versions = m.SortedVersions()
+	if _, ok := m.Versions[info.LTS]; !ok {
+		info.LTS = ""
+	}
+	g.Languages[m.Language] = info
+}
+
+func sortVersions(keys []string) {
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := keys[i], keys[j]
+		switch aOK, bOK := ValidVersion(a), ValidVersion(b); {
+		case aOK && bOK:
+			return CompareVersions(a, b) < 0
+		case aOK != bOK:
+			return aOK
+		}
+		return a < b
+	})
+}
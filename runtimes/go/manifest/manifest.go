@@ -0,0 +1,130 @@
+// Package manifest reads and writes the manifest.json files that describe
+// each language runtime published by wasmhub.
+package manifest
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// WASIVersions are the accepted values of Version.WASI.
+var WASIVersions = []string{"wasip1", "wasip2"}
+
+// Runtime is a per-language manifest, e.g. runtimes/go/manifest.json.
+type Runtime struct {
+	Language string             `json:"language"`
+	Latest   string             `json:"latest"`
+	Versions map[string]Version `json:"versions"`
+}
+
+// Version describes one published wasm binary of a runtime.
+type Version struct {
+	File     string   `json:"file"`
+	Size     int64    `json:"size"`
+	SHA256   string   `json:"sha256"`
+	Released string   `json:"released"`
+	WASI     string   `json:"wasi"`
+	Features []string `json:"features"`
+}
+
+// Parse decodes a per-language manifest.
+func Parse(data []byte) (*Runtime, error) {
+	var m Runtime
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("invalid manifest: %w", err)
+	}
+	if m.Language == "" || m.Versions == nil {
+		return nil, fmt.Errorf("invalid manifest: missing language or versions")
+	}
+	return &m, nil
+}
+
+// Marshal encodes m in the layout used by the committed manifests.
+func (m *Runtime) Marshal() ([]byte, error) {
+	data, err := json.MarshalIndent(m, "", "    ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// SetVersion adds or replaces a version and points Latest at the highest
+// version by semver precedence, whichever order versions were added in.
+func (m *Runtime) SetVersion(version string, v Version) {
+	if m.Versions == nil {
+		m.Versions = make(map[string]Version)
+	}
+	if v.Features == nil {
+		v.Features = []string{}
+	}
+	m.Versions[version] = v
+
+	m.Latest = ""
+	for key := range m.Versions {
+		if ValidVersion(key) && (m.Latest == "" || CompareVersions(key, m.Latest) > 0) {
+			m.Latest = key
+		}
+	}
+}
+
+// Validate reports every schema problem in m.
+func (m *Runtime) Validate() error {
+	var errs []error
+	if m.Language == "" {
+		errs = append(errs, errors.New("language is empty"))
+	}
+	if len(m.Versions) == 0 {
+		errs = append(errs, errors.New("versions is empty"))
+	}
+	if _, ok := m.Versions[m.Latest]; !ok {
+		errs = append(errs, fmt.Errorf("latest %q is not in versions", m.Latest))
+	}
+
+	for _, key := range m.SortedVersions() {
+		v := m.Versions[key]
+		prefix := fmt.Sprintf("versions[%q]", key)
+		if !ValidVersion(key) {
+			errs = append(errs, fmt.Errorf("%s: invalid version key", prefix))
+		}
+		if v.File == "" || strings.ContainsAny(v.File, `/\`) {
+			errs = append(errs, fmt.Errorf("%s: file must be a bare file name", prefix))
+		}
+		if v.Size <= 0 {
+			errs = append(errs, fmt.Errorf("%s: size must be positive", prefix))
+		}
+		if b, err := hex.DecodeString(v.SHA256); err != nil || len(b) != 32 {
+			errs = append(errs, fmt.Errorf("%s: sha256 must be 64 hex characters", prefix))
+		}
+		if _, err := time.Parse(time.RFC3339, v.Released); err != nil {
+			errs = append(errs, fmt.Errorf("%s: released must be an RFC 3339 timestamp", prefix))
+		}
+		if !validWASI(v.WASI) {
+			errs = append(errs, fmt.Errorf("%s: wasi must be one of %s", prefix, strings.Join(WASIVersions, ", ")))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// SortedVersions returns the version keys in ascending semver order.
+// Invalid keys sort last, alphabetically.
+func (m *Runtime) SortedVersions() []string {
+	keys := make([]string, 0, len(m.Versions))
+	for key := range m.Versions {
+		keys = append(keys, key)
+	}
+	sortVersions(keys)
+	return keys
+}
+
+func validWASI(w string) bool {
+	for _, v := range WASIVersions {
+		if w == v {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,125 @@
+package manifest
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"1.23", "1.9", 1},
+		{"1.9", "1.23", -1},
+		{"1.23", "1.23.0", 0},
+		{"20.2.0", "18.19.0", 1},
+		{"1.24.0-rc1", "1.24.0", -1},
+		{"1.24.0-rc.2", "1.24.0-rc.10", -1},
+		{"1.24.0-alpha", "1.24.0-alpha.1", -1},
+		{"1.24.0-1", "1.24.0-alpha", -1},
+		{"1.24.0-beta", "1.24.0-alpha", 1},
+	}
+	for _, tt := range tests {
+		if got := CompareVersions(tt.a, tt.b); got != tt.want {
+			t.Errorf("CompareVersions(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestValidVersion(t *testing.T) {
+	for _, v := range []string{"1", "1.23", "3.11.7", "1.24.0-rc1", "1.0.0-alpha.1"} {
+		if !ValidVersion(v) {
+			t.Errorf("ValidVersion(%q) = false", v)
+		}
+	}
+	for _, v := range []string{"", "v1.23", "1..2", "1.2.3.4", "1.23-", "1.23-rc..1", "latest"} {
+		if ValidVersion(v) {
+			t.Errorf("ValidVersion(%q) = true", v)
+		}
+	}
+}
+
+func TestSetVersionPicksHighestLatest(t *testing.T) {
+	m := &Runtime{Language: "go"}
+	for _, v := range []string{"1.23", "1.9", "1.24.0-rc1", "1.22"} {
+		m.SetVersion(v, Version{File: "go-" + v + ".wasm"})
+	}
+	if m.Latest != "1.24.0-rc1" {
+		t.Errorf("Latest = %q, want 1.24.0-rc1", m.Latest)
+	}
+	if got := m.Versions["1.9"].Features; got == nil {
+		t.Error("Features should be encoded as [] rather than null")
+	}
+	want := []string{"1.9", "1.22", "1.23", "1.24.0-rc1"}
+	if got := m.SortedVersions(); !reflect.DeepEqual(got, want) {
+		t.Errorf("SortedVersions() = %v, want %v", got, want)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	m := &Runtime{
+		Language: "go",
+		Latest:   "2.0",
+		Versions: map[string]Version{
+			"1.23": {File: "go-1.23.wasm", Size: 1, SHA256: strings.Repeat("ab", 32), Released: "2026-02-03T13:23:13Z", WASI: "wasip1"},
+			"next": {File: "dir/go.wasm", SHA256: "abc", Released: "yesterday", WASI: "wasi"},
+		},
+	}
+	err := m.Validate()
+	if err == nil {
+		t.Fatal("Validate() = nil, want errors")
+	}
+	want := []string{
+		`latest "2.0" is not in versions`,
+		`versions["next"]: invalid version key`,
+		`versions["next"]: file must be a bare file name`,
+		`versions["next"]: size must be positive`,
+		`versions["next"]: sha256 must be 64 hex characters`,
+		`versions["next"]: released must be an RFC 3339 timestamp`,
+		`versions["next"]: wasi must be one of wasip1, wasip2`,
+	}
+	if got := strings.Split(err.Error(), "\n"); !reflect.DeepEqual(got, want) {
+		t.Errorf("Validate() errors:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestCommittedManifestsRoundTrip(t *testing.T) {
+	for _, path := range []string{"../manifest.json", "../../rust/manifest.json"} {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		m, err := Parse(data)
+		if err != nil {
+			t.Fatalf("%s: %v", path, err)
+		}
+		if err := m.Validate(); err != nil {
+			t.Errorf("%s: %v", path, err)
+		}
+		out, err := m.Marshal()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(out) != string(data) {
+			t.Errorf("%s does not round-trip:\n%s", path, out)
+		}
+	}
+}
+
+func TestGlobalSetLanguageKeepsMetadata(t *testing.T) {
+	g := &Global{Version: "1.0.0", Languages: map[string]LanguageInfo{
+		"go": {Latest: "1.22", LTS: "1.22", Versions: []string{"1.22"}, Source: "https://example.com/go", License: "BSD"},
+	}}
+	m := &Runtime{Language: "go"}
+	m.SetVersion("1.22", Version{})
+	m.SetVersion("1.23", Version{})
+	g.SetLanguage(m, "ignored", "ignored")
+
+	want := LanguageInfo{Latest: "1.23", LTS: "1.22", Versions: []string{"1.22", "1.23"}, Source: "https://example.com/go", License: "BSD"}
+	if got := g.Languages["go"]; !reflect.DeepEqual(got, want) {
+		t.Errorf("Languages[go] = %+v, want %+v", got, want)
+	}
+}
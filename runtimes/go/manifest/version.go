@@ -0,0 +1,132 @@
+package manifest
+
+import (
+	"strconv"
+	"strings"
+)
+
+// ValidVersion reports whether v is a version key this project publishes:
+// one to three dot-separated numbers, optionally followed by a
+// "-prerelease" suffix, e.g. "1.23", "3.11.7" or "1.24.0-rc1".
+func ValidVersion(v string) bool {
+	core, pre, hasPre := strings.Cut(v, "-")
+	if hasPre && !validPrerelease(pre) {
+		return false
+	}
+	parts := strings.Split(core, ".")
+	if len(parts) > 3 {
+		return false
+	}
+	for _, p := range parts {
+		if !isNumeric(p) {
+			return false
+		}
+	}
+	return true
+}
+
+// CompareVersions orders two valid version keys by semver precedence. A
+// shorter core version is padded with zeros, so "1.23" == "1.23.0", and a
+// prerelease sorts before the release it precedes.
+func CompareVersions(a, b string) int {
+	aCore, aPre, _ := strings.Cut(a, "-")
+	bCore, bPre, _ := strings.Cut(b, "-")
+
+	aParts, bParts := strings.Split(aCore, "."), strings.Split(bCore, ".")
+	for i := range max(len(aParts), len(bParts)) {
+		if c := compareNumeric(part(aParts, i), part(bParts, i)); c != 0 {
+			return c
+		}
+	}
+
+	switch {
+	case aPre == bPre:
+		return 0
+	case aPre == "":
+		return 1
+	case bPre == "":
+		return -1
+	}
+	return comparePrerelease(aPre, bPre)
+}
+
+func part(parts []string, i int) string {
+	if i < len(parts) {
+		return parts[i]
+	}
+	return "0"
+}
+
+// comparePrerelease applies the semver rules: identifiers are compared left
+// to right, numeric ones numerically and below alphanumeric ones, and a
+// shorter list of otherwise equal identifiers sorts first.
+func comparePrerelease(a, b string) int {
+	aIDs, bIDs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(aIDs) && i < len(bIDs); i++ {
+		x, y := aIDs[i], bIDs[i]
+		xNum, yNum := isNumeric(x), isNumeric(y)
+		var c int
+		switch {
+		case xNum && yNum:
+			c = compareNumeric(x, y)
+		case xNum:
+			c = -1
+		case yNum:
+			c = 1
+		default:
+			c = strings.Compare(x, y)
+		}
+		if c != 0 {
+			return c
+		}
+	}
+	return compareInts(len(aIDs), len(bIDs))
+}
+
+func compareNumeric(a, b string) int {
+	x, _ := strconv.ParseUint(a, 10, 64)
+	y, _ := strconv.ParseUint(b, 10, 64)
+	switch {
+	case x < y:
+		return -1
+	case x > y:
+		return 1
+	}
+	return 0
+}
+
+func compareInts(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func isNumeric(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func validPrerelease(s string) bool {
+	for _, id := range strings.Split(s, ".") {
+		if id == "" {
+			return false
+		}
+		for _, c := range id {
+			if !(c == '-' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z') {
+				return false
+			}
+		}
+	}
+	return true
+}
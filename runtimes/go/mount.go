@@ -0,0 +1,154 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+// mountFlag collects the repeated --mount ARCHIVE:PATH values.
+type mountFlag []string
+
+func (m *mountFlag) String() string {
+	return strings.Join(*m, ",")
+}
+
+func (m *mountFlag) Set(v string) error {
+	i := strings.LastIndex(v, ":")
+	if i <= 0 || i == len(v)-1 {
+		return fmt.Errorf("invalid mount %q, expected ARCHIVE:PATH", v)
+	}
+	*m = append(*m, v)
+	return nil
+}
+
+var errReadOnly = errors.New("read-only file system")
+
+// mountFS serves paths under each mount point from an archive and
+// everything else from lower. Mounted paths are read-only.
+type mountFS struct {
+	lower  fileSystem
+	mounts []mountPoint
+}
+
+type mountPoint struct {
+	path string
+	fsys fs.FS
+}
+
+// mountArchives replaces e.fs with a mountFS for the given specs. Archives
+// are read through the filesystem as it was before mounting.
+func mountArchives(e *environment, specs []string) error {
+	m := &mountFS{lower: e.fs}
+	for _, spec := range specs {
+		i := strings.LastIndex(spec, ":")
+		archive, point := spec[:i], path.Clean(spec[i+1:])
+		fsys, err := openArchive(e.fs, archive)
+		if err != nil {
+			return fmt.Errorf("%s: %w", archive, err)
+		}
+		m.mounts = append(m.mounts, mountPoint{path: point, fsys: fsys})
+	}
+	e.fs = m
+	return nil
+}
+
+// resolve returns the archive mounted over name and the path of name inside
+// it. Later mounts shadow earlier ones.
+func (m *mountFS) resolve(name string) (fs.FS, string, bool) {
+	p := path.Clean(name)
+	for i := len(m.mounts) - 1; i >= 0; i-- {
+		mp := m.mounts[i]
+		switch {
+		case p == mp.path:
+			return mp.fsys, ".", true
+		case mp.path == "/" && path.IsAbs(p):
+			return mp.fsys, p[1:], true
+		case mp.path == "." && !path.IsAbs(p) && p != ".." && !strings.HasPrefix(p, "../"):
+			return mp.fsys, p, true
+		case strings.HasPrefix(p, mp.path+"/"):
+			return mp.fsys, p[len(mp.path)+1:], true
+		}
+	}
+	return nil, "", false
+}
+
+func (m *mountFS) ReadFile(name string) ([]byte, error) {
+	if fsys, rel, ok := m.resolve(name); ok {
+		data, err := fs.ReadFile(fsys, rel)
+		return data, withPath(err, name)
+	}
+	return m.lower.ReadFile(name)
+}
+
+func (m *mountFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	if fsys, rel, ok := m.resolve(name); ok {
+		entries, err := fs.ReadDir(fsys, rel)
+		return entries, withPath(err, name)
+	}
+
+	entries, err := m.lower.ReadDir(name)
+	dir := path.Clean(name)
+	var points []fs.DirEntry
+	for _, mp := range m.mounts {
+		if mp.path != dir && path.Dir(mp.path) == dir {
+			points = append(points, fs.FileInfoToDirEntry(mountPointInfo(path.Base(mp.path))))
+		}
+	}
+	if len(points) == 0 {
+		return entries, err
+	}
+
+	// Mount points hide any lower entry of the same name.
+	merged := points
+	for _, entry := range entries {
+		hidden := false
+		for _, p := range points {
+			hidden = hidden || p.Name() == entry.Name()
+		}
+		if !hidden {
+			merged = append(merged, entry)
+		}
+	}
+	sort.Slice(merged, func(i, j int) bool { return merged[i].Name() < merged[j].Name() })
+	return merged, nil
+}
+
+func (m *mountFS) Stat(name string) (fs.FileInfo, error) {
+	if fsys, rel, ok := m.resolve(name); ok {
+		info, err := fs.Stat(fsys, rel)
+		return info, withPath(err, name)
+	}
+	return m.lower.Stat(name)
+}
+
+func (m *mountFS) WriteFile(name string, data []byte, perm fs.FileMode) error {
+	if _, _, ok := m.resolve(name); ok {
+		return &fs.PathError{Op: "open", Path: name, Err: errReadOnly}
+	}
+	return m.lower.WriteFile(name, data, perm)
+}
+
+// withPath reports archive errors against the path the user asked for
+// rather than the path inside the archive.
+func withPath(err error, name string) error {
+	var pe *fs.PathError
+	if errors.As(err, &pe) {
+		return &fs.PathError{Op: pe.Op, Path: name, Err: pe.Err}
+	}
+	return err
+}
+
+// mountPointInfo describes a mount point in the listing of its parent.
+type mountPointInfo string
+
+func (i mountPointInfo) Name() string       { return string(i) }
+func (i mountPointInfo) Size() int64        { return 0 }
+func (i mountPointInfo) Mode() fs.FileMode  { return fs.ModeDir | 0555 }
+func (i mountPointInfo) ModTime() time.Time { return time.Time{} }
+func (i mountPointInfo) IsDir() bool        { return true }
+func (i mountPointInfo) Sys() any           { return nil }
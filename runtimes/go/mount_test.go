@@ -0,0 +1,121 @@
+package main
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io/fs"
+	"testing"
+	"testing/fstest"
+)
+
+var archiveFiles = []struct {
+	name, body string
+}{
+	{"README.md", "# submission\n"},
+	{"src/main.go", "package main\n\nfunc main() {}\n"},
+	{"src/util/str.go", "package util\n"},
+}
+
+func buildZip(t *testing.T) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, f := range archiveFiles {
+		w, err := zw.Create(f.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(f.body))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func buildTar(t *testing.T) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for _, f := range archiveFiles {
+		hdr := &tar.Header{Name: "./" + f.name, Mode: 0644, Size: int64(len(f.body)), Typeflag: tar.TypeReg}
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatal(err)
+		}
+		tw.Write([]byte(f.body))
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func buildTarGz(t *testing.T) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	zw.Write(buildTar(t))
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func newMountTestFS(t *testing.T) *memFS {
+	fsys := newTestFS()
+	fsys.files["sub.zip"] = &fstest.MapFile{Data: buildZip(t)}
+	fsys.files["sub.tar"] = &fstest.MapFile{Data: buildTar(t)}
+	fsys.files["sub.tar.gz"] = &fstest.MapFile{Data: buildTarGz(t)}
+	fsys.files["notes.rar"] = &fstest.MapFile{Data: []byte("Rar!")}
+	return fsys
+}
+
+func TestMountGolden(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{"mount_ls_root", []string{"--mount", "sub.zip:proj", "ls", "proj"}},
+		{"mount_ls_parent", []string{"--mount", "sub.zip:src/proj", "ls", "src"}},
+		{"mount_cat", []string{"--mount", "sub.tar.gz:proj", "cat", "proj/src/main.go"}},
+		{"mount_cat_missing", []string{"--mount", "sub.zip:proj", "cat", "proj/missing.go"}},
+		{"mount_write", []string{"--mount", "sub.zip:proj", "write", "proj/README.md", "x"}},
+		{"mount_bad_spec", []string{"--mount", "sub.zip", "ls"}},
+		{"mount_unsupported", []string{"--mount", "notes.rar:proj", "ls"}},
+		{"mount_archive_missing", []string{"--mount", "gone.zip:proj", "ls"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checkGolden(t, tt.name, transcript(newMountTestFS(t), tt.args...))
+		})
+	}
+}
+
+func TestMountArchiveFormats(t *testing.T) {
+	for _, archive := range []string{"sub.zip", "sub.tar", "sub.tar.gz"} {
+		t.Run(archive, func(t *testing.T) {
+			fsys, err := openArchive(newMountTestFS(t), archive)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := fstest.TestFS(fsys, "README.md", "src/main.go", "src/util/str.go"); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestOverlayOverMount(t *testing.T) {
+	fsys := newMountTestFS(t)
+	got := transcript(fsys, "--mount", "sub.tar:proj", "--overlay", "write", "proj/new.txt", "x")
+	want := "exit: 0\n-- stdout --\nWrote 1 bytes to proj/new.txt\n-- stderr --\n"
+	if got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+	if _, err := fsys.Stat("proj/new.txt"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("overlay write leaked below the mount: %v", err)
+	}
+}
@@ -0,0 +1,326 @@
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"sort"
+	"time"
+)
+
+// overlayFlag is the value of --overlay. The bare flag enables an in-memory
+// layer for the current run; --overlay=STATE also loads the layer from and
+// saves it back to a tar file, so changes survive across invocations.
+type overlayFlag struct {
+	enabled bool
+	state   string
+}
+
+func (f *overlayFlag) String() string {
+	return f.state
+}
+
+func (f *overlayFlag) Set(v string) error {
+	switch v {
+	case "true":
+		f.enabled = true
+	case "false":
+		f.enabled, f.state = false, ""
+	default:
+		f.enabled, f.state = true, v
+	}
+	return nil
+}
+
+func (f *overlayFlag) IsBoolFlag() bool { return true }
+
+// overlayFS is a copy-on-write fileSystem. Reads fall through to lower
+// unless the path has been written; writes only ever touch the in-memory
+// layer until they are committed.
+type overlayFS struct {
+	lower fileSystem
+	now   func() time.Time
+	files map[string]*overlayFile
+	state string // cleaned path of the state file, "" for an in-memory layer
+	dirty bool   // the layer changed since it was loaded
+}
+
+type overlayFile struct {
+	data    []byte
+	mode    fs.FileMode
+	modTime time.Time
+}
+
+// overlayChange is one entry in the output of `overlay diff`: 'A' for a file
+// that does not exist below the overlay, 'M' for one whose content differs.
+type overlayChange struct {
+	kind byte
+	path string
+}
+
+var errIsDir = errors.New("is a directory")
+
+func newOverlayFS(lower fileSystem, now func() time.Time) *overlayFS {
+	return &overlayFS{lower: lower, now: now, files: make(map[string]*overlayFile)}
+}
+
+// mountOverlay replaces e.fs with an overlay on top of it, preloading the
+// layer from the state file when one is given and already exists. The state
+// file itself is hidden from listings and never shows up as a change.
+func mountOverlay(e *environment, state string) (*overlayFS, error) {
+	ov := newOverlayFS(e.fs, e.now)
+	if state != "" {
+		ov.state = path.Clean(state)
+		data, err := e.fs.ReadFile(state)
+		switch {
+		case errors.Is(err, fs.ErrNotExist):
+		case err != nil:
+			return nil, err
+		default:
+			if err := ov.readTar(bytes.NewReader(data)); err != nil {
+				return nil, fmt.Errorf("%s: %w", state, err)
+			}
+		}
+	}
+	e.fs = ov
+	return ov, nil
+}
+
+// save writes the layer to the state file on the lower filesystem. It does
+// nothing for an in-memory layer or when the run left the layer unchanged,
+// so read-only commands never create or touch the state file.
+func (o *overlayFS) save() error {
+	if o.state == "" || !o.dirty {
+		return nil
+	}
+	var buf bytes.Buffer
+	if err := o.writeTar(&buf, o.paths()); err != nil {
+		return err
+	}
+	return o.lower.WriteFile(o.state, buf.Bytes(), 0644)
+}
+
+func (o *overlayFS) ReadFile(name string) ([]byte, error) {
+	if f, ok := o.files[path.Clean(name)]; ok {
+		return bytes.Clone(f.data), nil
+	}
+	return o.lower.ReadFile(name)
+}
+
+func (o *overlayFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	dir := path.Clean(name)
+	lower, err := o.lower.ReadDir(name)
+
+	merged := make(map[string]fs.DirEntry)
+	for _, entry := range lower {
+		if o.state != "" && path.Join(dir, entry.Name()) == o.state {
+			continue
+		}
+		merged[entry.Name()] = entry
+	}
+	layered := 0
+	for p, f := range o.files {
+		if path.Dir(p) == dir {
+			info := overlayFileInfo{name: path.Base(p), file: f}
+			merged[info.name] = fs.FileInfoToDirEntry(info)
+			layered++
+		}
+	}
+	if err != nil && layered == 0 {
+		return nil, err
+	}
+
+	entries := make([]fs.DirEntry, 0, len(merged))
+	for _, entry := range merged {
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+	return entries, nil
+}
+
+func (o *overlayFS) Stat(name string) (fs.FileInfo, error) {
+	p := path.Clean(name)
+	if f, ok := o.files[p]; ok {
+		return overlayFileInfo{name: path.Base(p), file: f}, nil
+	}
+	return o.lower.Stat(name)
+}
+
+func (o *overlayFS) WriteFile(name string, data []byte, perm fs.FileMode) error {
+	p := path.Clean(name)
+	if p == o.state {
+		return &fs.PathError{Op: "open", Path: name, Err: fs.ErrPermission}
+	}
+	if _, ok := o.files[p]; !ok {
+		if info, err := o.lower.Stat(name); err == nil && info.IsDir() {
+			return &fs.PathError{Op: "open", Path: name, Err: errIsDir}
+		}
+		if dir := path.Dir(p); dir != "." && dir != "/" {
+			if info, err := o.Stat(dir); err != nil || !info.IsDir() {
+				return &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+			}
+		}
+	}
+	o.files[p] = &overlayFile{data: bytes.Clone(data), mode: perm, modTime: o.now()}
+	o.dirty = true
+	return nil
+}
+
+func (o *overlayFS) paths() []string {
+	paths := make([]string, 0, len(o.files))
+	for p := range o.files {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// changes compares the layer against the lower filesystem, leaving out
+// files that were rewritten with identical content.
+func (o *overlayFS) changes() []overlayChange {
+	var changes []overlayChange
+	for _, p := range o.paths() {
+		lower, err := o.lower.ReadFile(p)
+		switch {
+		case err != nil:
+			changes = append(changes, overlayChange{kind: 'A', path: p})
+		case !bytes.Equal(lower, o.files[p].data):
+			changes = append(changes, overlayChange{kind: 'M', path: p})
+		}
+	}
+	return changes
+}
+
+// commit writes every changed file through to the lower filesystem and
+// empties the layer.
+func (o *overlayFS) commit() (int, error) {
+	changes := o.changes()
+	for _, c := range changes {
+		f := o.files[c.path]
+		if err := o.lower.WriteFile(c.path, f.data, f.mode); err != nil {
+			return 0, err
+		}
+	}
+	if len(o.files) > 0 {
+		o.files = make(map[string]*overlayFile)
+		o.dirty = true
+	}
+	return len(changes), nil
+}
+
+func (o *overlayFS) writeTar(w io.Writer, paths []string) error {
+	tw := tar.NewWriter(w)
+	for _, p := range paths {
+		f := o.files[p]
+		hdr := &tar.Header{
+			Typeflag: tar.TypeReg,
+			Name:     p,
+			Mode:     int64(f.mode.Perm()),
+			Size:     int64(len(f.data)),
+			ModTime:  f.modTime,
+			Format:   tar.FormatPAX,
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if _, err := tw.Write(f.data); err != nil {
+			return err
+		}
+	}
+	return tw.Close()
+}
+
+func (o *overlayFS) readTar(r io.Reader) error {
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			return err
+		}
+		o.files[path.Clean(hdr.Name)] = &overlayFile{
+			data:    data,
+			mode:    fs.FileMode(hdr.Mode).Perm(),
+			modTime: hdr.ModTime,
+		}
+	}
+}
+
+// overlayFileInfo describes a file held in the layer.
+type overlayFileInfo struct {
+	name string
+	file *overlayFile
+}
+
+func (i overlayFileInfo) Name() string       { return i.name }
+func (i overlayFileInfo) Size() int64        { return int64(len(i.file.data)) }
+func (i overlayFileInfo) Mode() fs.FileMode  { return i.file.mode }
+func (i overlayFileInfo) ModTime() time.Time { return i.file.modTime }
+func (i overlayFileInfo) IsDir() bool        { return false }
+func (i overlayFileInfo) Sys() any           { return nil }
+
+func overlayCommand(e *environment, args []string) int {
+	ov, ok := e.fs.(*overlayFS)
+	if !ok {
+		fmt.Fprintln(e.stderr, "Error: overlay requires the --overlay flag")
+		return 1
+	}
+	// A bare --overlay layer lives for a single command, so there is never
+	// anything to diff, commit or export by the time this one runs.
+	if ov.state == "" {
+		fmt.Fprintln(e.stderr, "Error: overlay needs a state file to keep changes between runs; use --overlay=STATE")
+		return 1
+	}
+
+	switch sub := args[0]; {
+	case sub == "diff" && len(args) == 1:
+		changes := ov.changes()
+		if len(changes) == 0 {
+			fmt.Fprintln(e.stdout, "No changes")
+		}
+		for _, c := range changes {
+			fmt.Fprintf(e.stdout, "%c %s\n", c.kind, c.path)
+		}
+		return 0
+	case sub == "commit" && len(args) == 1:
+		n, err := ov.commit()
+		if err != nil {
+			fmt.Fprintf(e.stderr, "Error committing overlay: %v\n", err)
+			return 1
+		}
+		fmt.Fprintf(e.stdout, "Committed %d file(s)\n", n)
+		return 0
+	case sub == "export" && len(args) == 2:
+		var paths []string
+		for _, c := range ov.changes() {
+			paths = append(paths, c.path)
+		}
+		var buf bytes.Buffer
+		if err := ov.writeTar(&buf, paths); err != nil {
+			fmt.Fprintf(e.stderr, "Error exporting overlay: %v\n", err)
+			return 1
+		}
+		if err := ov.lower.WriteFile(args[1], buf.Bytes(), 0644); err != nil {
+			fmt.Fprintf(e.stderr, "Error writing %s: %v\n", args[1], err)
+			return 1
+		}
+		fmt.Fprintf(e.stdout, "Exported %d file(s) to %s\n", len(paths), args[1])
+		return 0
+	default:
+		fmt.Fprintln(e.stderr, "Error: overlay expects diff, commit or export <file>")
+		return 1
+	}
+}
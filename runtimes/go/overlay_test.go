@@ -0,0 +1,134 @@
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestOverlayLeavesLowerUntouched(t *testing.T) {
+	fsys := newTestFS()
+	got := transcript(fsys, "--overlay", "write", "hello.txt", "changed")
+	if !strings.HasPrefix(got, "exit: 0\n") {
+		t.Fatalf("write failed:\n%s", got)
+	}
+	data, _ := fsys.ReadFile("hello.txt")
+	if string(data) != "Hello, WasmHub!\n" {
+		t.Errorf("lower file was modified: %q", data)
+	}
+}
+
+func TestOverlayGolden(t *testing.T) {
+	const state = "--overlay=state.tar"
+	tests := []struct {
+		name  string
+		setup [][]string
+		args  []string
+	}{
+		{"overlay_required", nil, []string{"overlay", "diff"}},
+		{"overlay_no_state", nil, []string{"--overlay", "overlay", "diff"}},
+		{"overlay_bad_subcommand", nil, []string{state, "overlay", "rebase"}},
+		{"overlay_diff_empty", nil, []string{state, "overlay", "diff"}},
+		{
+			"overlay_diff",
+			[][]string{
+				{state, "write", "hello.txt", "changed"},
+				{state, "write", "src/new.go", "package main\n"},
+				{state, "write", "empty.txt", ""},
+			},
+			[]string{state, "overlay", "diff"},
+		},
+		{
+			"overlay_ls_merged",
+			[][]string{{state, "write", "src/new.go", "package main\n"}},
+			[]string{state, "ls", "src"},
+		},
+		{
+			"overlay_cat",
+			[][]string{{state, "write", "hello.txt", "from the overlay\n"}},
+			[]string{state, "cat", "hello.txt"},
+		},
+		{"overlay_write_missing_dir", nil, []string{state, "write", "nowhere/a.txt", "x"}},
+		{"overlay_write_dir", nil, []string{state, "write", "src", "x"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fsys := newTestFS()
+			for _, args := range tt.setup {
+				if got := transcript(fsys, args...); !strings.HasPrefix(got, "exit: 0\n") {
+					t.Fatalf("setup %v failed:\n%s", args, got)
+				}
+			}
+			checkGolden(t, tt.name, transcript(fsys, tt.args...))
+		})
+	}
+}
+
+func TestOverlayStateFile(t *testing.T) {
+	fsys := newTestFS()
+	transcript(fsys, "--overlay=state.tar", "cat", "hello.txt")
+	if _, err := fsys.ReadFile("state.tar"); err == nil {
+		t.Fatal("read-only run wrote the state file")
+	}
+
+	transcript(fsys, "--overlay=state.tar", "write", "a.txt", "alpha")
+	if _, err := fsys.ReadFile("state.tar"); err != nil {
+		t.Fatalf("state file not saved: %v", err)
+	}
+	if got := transcript(fsys, "--overlay=state.tar", "ls"); strings.Contains(got, "state.tar") {
+		t.Errorf("ls lists the state file:\n%s", got)
+	}
+	if got := transcript(fsys, "--overlay=state.tar", "write", "state.tar", "x"); !strings.HasPrefix(got, "exit: 1\n") {
+		t.Errorf("writing the state file through the overlay succeeded:\n%s", got)
+	}
+}
+
+func TestOverlayCommit(t *testing.T) {
+	fsys := newTestFS()
+	transcript(fsys, "--overlay=state.tar", "write", "hello.txt", "committed")
+	if _, err := fsys.ReadFile("hello.txt"); err != nil {
+		t.Fatal(err)
+	}
+
+	got := transcript(fsys, "--overlay=state.tar", "overlay", "commit")
+	if !strings.Contains(got, "Committed 1 file(s)") {
+		t.Fatalf("unexpected commit output:\n%s", got)
+	}
+	data, _ := fsys.ReadFile("hello.txt")
+	if string(data) != "committed" {
+		t.Errorf("hello.txt = %q after commit", data)
+	}
+	if got := transcript(fsys, "--overlay=state.tar", "overlay", "diff"); !strings.Contains(got, "No changes") {
+		t.Errorf("layer not cleared after commit:\n%s", got)
+	}
+}
+
+func TestOverlayExport(t *testing.T) {
+	fsys := newTestFS()
+	transcript(fsys, "--overlay=state.tar", "write", "a.txt", "alpha")
+	transcript(fsys, "--overlay=state.tar", "write", "empty.txt", "")
+	got := transcript(fsys, "--overlay=state.tar", "overlay", "export", "changes.tar")
+	if !strings.Contains(got, "Exported 1 file(s) to changes.tar") {
+		t.Fatalf("unexpected export output:\n%s", got)
+	}
+
+	data, err := fsys.ReadFile("changes.tar")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr := tar.NewReader(bytes.NewReader(data))
+	hdr, err := tr.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(tr)
+	if hdr.Name != "a.txt" || string(body) != "alpha" {
+		t.Errorf("exported %s = %q, want a.txt = %q", hdr.Name, body, "alpha")
+	}
+	if _, err := tr.Next(); err != io.EOF {
+		t.Errorf("expected a single entry in the export, got err=%v", err)
+	}
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// command describes a single runtime subcommand. Dispatch, argument checking
+// and all help output are derived from these fields.
+type command struct {
+	name    string
+	aliases []string
+	args    string // argument synopsis, e.g. "<file> <content>"
+	minArgs int
+	maxArgs int // -1 for no limit
+	summary string
+	rawArgs bool // pass arguments through unparsed; only a leading --help is special
+
+	// interspersed lets flags appear after positional arguments, as in
+	// "wasm dis hello.wasm --func main".
+	interspersed bool
+
+	flags *flag.FlagSet
+	run   func(e *environment, args []string) int
+}
+
+// registry holds the commands known to one invocation. It is rebuilt for
+// every dispatch so flag values never leak between runs.
+type registry struct {
+	commands []*command
+	globals  *flag.FlagSet
+}
+
+func newRegistry() *registry {
+	r := &registry{}
+	registerBuiltins(r)
+	return r
+}
+
+// register adds cmd to the registry and gives it an empty flag set that the
+// caller may define flags on before the command runs.
+func (r *registry) register(cmd *command) *command {
+	cmd.flags = flag.NewFlagSet(cmd.name, flag.ContinueOnError)
+	cmd.flags.SetOutput(io.Discard)
+	r.commands = append(r.commands, cmd)
+	return cmd
+}
+
+func (r *registry) lookup(name string) *command {
+	for _, cmd := range r.commands {
+		if cmd.name == name {
+			return cmd
+		}
+		for _, alias := range cmd.aliases {
+			if alias == name {
+				return cmd
+			}
+		}
+	}
+	return nil
+}
+
+func (r *registry) printUsage(w io.Writer) {
+	fmt.Fprintln(w, "WasmHub Go Runtime")
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "Usage: %s [global flags] <command> [args...]\n", progName)
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+
+	width := 0
+	for _, cmd := range r.commands {
+		width = max(width, len(cmd.usageLine()))
+	}
+	for _, cmd := range r.commands {
+		fmt.Fprintf(w, "  %-*s  %s\n", width, cmd.usageLine(), cmd.summary)
+	}
+
+	if r.globals != nil {
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, "Global flags:")
+		r.globals.SetOutput(w)
+		r.globals.PrintDefaults()
+		r.globals.SetOutput(io.Discard)
+	}
+
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "Run '%s help <command>' for details on a command.\n", progName)
+}
+
+// execute parses flags and validates the argument count before handing the
+// remaining arguments to the command.
+func (c *command) execute(e *environment, args []string) int {
+	rest := args
+	if c.rawArgs {
+		if len(args) > 0 && args[0] == "--help" {
+			c.printHelp(e.stdout)
+			return 0
+		}
+	} else {
+		rest = nil
+		for {
+			if err := c.flags.Parse(args); err != nil {
+				if errors.Is(err, flag.ErrHelp) {
+					c.printHelp(e.stdout)
+					return 0
+				}
+				return c.usageError(e, fmt.Sprintf("%s: %v", c.name, err))
+			}
+			remaining := c.flags.Args()
+			// Parse consumes a "--" terminator; everything after it is an
+			// argument even for interspersed commands.
+			terminated := len(remaining) < len(args) && args[len(args)-len(remaining)-1] == "--"
+			if !c.interspersed || terminated || len(remaining) == 0 {
+				rest = append(rest, remaining...)
+				break
+			}
+			rest, args = append(rest, remaining[0]), remaining[1:]
+		}
+	}
+
+	if len(rest) < c.minArgs {
+		return c.usageError(e, fmt.Sprintf("%s requires %s", c.name, c.args))
+	}
+	if c.maxArgs == 0 && len(rest) > 0 {
+		return c.usageError(e, fmt.Sprintf("%s takes no arguments", c.name))
+	}
+	if c.maxArgs > 0 && len(rest) > c.maxArgs {
+		return c.usageError(e, fmt.Sprintf("%s accepts at most %d argument(s)", c.name, c.maxArgs))
+	}
+	return c.run(e, rest)
+}
+
+func (c *command) usageError(e *environment, msg string) int {
+	fmt.Fprintf(e.stderr, "Error: %s\n", msg)
+	fmt.Fprintf(e.stderr, "Usage: %s\n", c.synopsis())
+	return 1
+}
+
+func (c *command) hasFlags() bool {
+	n := 0
+	c.flags.VisitAll(func(*flag.Flag) { n++ })
+	return n > 0
+}
+
+func (c *command) usageLine() string {
+	return strings.TrimSpace(c.name + " " + c.args)
+}
+
+func (c *command) synopsis() string {
+	parts := []string{progName, c.name}
+	if c.hasFlags() {
+		parts = append(parts, "[flags]")
+	}
+	if c.args != "" {
+		parts = append(parts, c.args)
+	}
+	return strings.Join(parts, " ")
+}
+
+func (c *command) printHelp(w io.Writer) {
+	fmt.Fprintf(w, "Usage: %s\n", c.synopsis())
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, c.summary)
+	if len(c.aliases) > 0 {
+		fmt.Fprintln(w)
+		fmt.Fprintf(w, "Aliases: %s\n", strings.Join(c.aliases, ", "))
+	}
+	if c.hasFlags() {
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, "Flags:")
+		c.flags.SetOutput(w)
+		c.flags.PrintDefaults()
+		c.flags.SetOutput(io.Discard)
+	}
+}
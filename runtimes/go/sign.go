@@ -0,0 +1,283 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/anistark/wasmhub/runtimes/go/manifest"
+)
+
+// Keys and detached signatures are single-line text files:
+//
+//	name.key   ed25519-private <base64 32-byte seed>
+//	name.pub   ed25519-public <base64 32-byte public key>
+//	file.sig   ed25519 <key id> <base64 64-byte signature>
+//
+// The key id is the first 8 bytes of the SHA-256 of the public key, in hex.
+// It lets verify report a wrong key distinctly from a bad signature.
+const (
+	privateKeyTag = "ed25519-private"
+	publicKeyTag  = "ed25519-public"
+	signatureTag  = "ed25519"
+)
+
+var errWrongKey = errors.New("signed with a different key")
+
+func registerSigning(r *registry) {
+	keygen := r.register(&command{
+		name:    "keygen",
+		args:    "<name>",
+		minArgs: 1,
+		maxArgs: 1,
+		summary: "Generate an Ed25519 key pair as <name>.key and <name>.pub",
+	})
+	force := keygen.flags.Bool("force", false, "overwrite existing key files")
+	keygen.run = func(e *environment, args []string) int {
+		return generateKey(e, args[0], *force)
+	}
+
+	sign := r.register(&command{
+		name:    "sign",
+		args:    "<file>",
+		minArgs: 1,
+		maxArgs: 1,
+		summary: "Write a detached Ed25519 signature for a file",
+	})
+	signKey := sign.flags.String("key", "", "private key `FILE` (required)")
+	signOut := sign.flags.String("out", "", "signature `FILE` (default <file>.sig)")
+	sign.run = func(e *environment, args []string) int {
+		if *signKey == "" {
+			return sign.usageError(e, "sign: --key is required")
+		}
+		return signFile(e, *signKey, args[0], *signOut)
+	}
+
+	verify := r.register(&command{
+		name:    "verify",
+		args:    "<file> [signature]",
+		minArgs: 1,
+		maxArgs: 2,
+		summary: "Check a detached signature, and with --manifest every referenced wasm file",
+	})
+	verifyPub := verify.flags.String("pub", "", "public key `FILE` (required)")
+	isManifest := verify.flags.Bool("manifest", false, "treat <file> as a runtime manifest.json and check each version's sha256")
+	verify.run = func(e *environment, args []string) int {
+		if *verifyPub == "" {
+			return verify.usageError(e, "verify: --pub is required")
+		}
+		sigPath := args[0] + ".sig"
+		if len(args) > 1 {
+			sigPath = args[1]
+		}
+		return verifyFile(e, *verifyPub, args[0], sigPath, *isManifest)
+	}
+}
+
+func generateKey(e *environment, name string, force bool) int {
+	keyPath, pubPath := name+".key", name+".pub"
+	if !force {
+		for _, p := range []string{keyPath, pubPath} {
+			if _, err := e.fs.Stat(p); err == nil {
+				fmt.Fprintf(e.stderr, "Error: %s already exists (use --force to overwrite)\n", p)
+				return 1
+			}
+		}
+	}
+
+	seed := make([]byte, ed25519.SeedSize)
+	if _, err := io.ReadFull(e.rand, seed); err != nil {
+		fmt.Fprintf(e.stderr, "Error generating key: %v\n", err)
+		return 1
+	}
+	priv := ed25519.NewKeyFromSeed(seed)
+	pub := priv.Public().(ed25519.PublicKey)
+
+	if err := e.fs.WriteFile(keyPath, encodeKeyLine(privateKeyTag, seed), 0600); err != nil {
+		fmt.Fprintf(e.stderr, "Error writing %s: %v\n", keyPath, err)
+		return 1
+	}
+	if err := e.fs.WriteFile(pubPath, encodeKeyLine(publicKeyTag, pub), 0644); err != nil {
+		fmt.Fprintf(e.stderr, "Error writing %s: %v\n", pubPath, err)
+		return 1
+	}
+	fmt.Fprintf(e.stdout, "Wrote %s and %s (key id %s)\n", keyPath, pubPath, keyID(pub))
+	return 0
+}
+
+func signFile(e *environment, keyPath, file, out string) int {
+	priv, err := readPrivateKey(e.fs, keyPath)
+	if err != nil {
+		fmt.Fprintf(e.stderr, "Error reading key %s: %v\n", keyPath, err)
+		return 1
+	}
+	data, err := e.fs.ReadFile(file)
+	if err != nil {
+		fmt.Fprintf(e.stderr, "Error reading %s: %v\n", file, err)
+		return 1
+	}
+
+	if out == "" {
+		out = file + ".sig"
+	}
+	pub := priv.Public().(ed25519.PublicKey)
+	sig := fmt.Sprintf("%s %s %s\n", signatureTag, keyID(pub), base64.StdEncoding.EncodeToString(ed25519.Sign(priv, data)))
+	if err := e.fs.WriteFile(out, []byte(sig), 0644); err != nil {
+		fmt.Fprintf(e.stderr, "Error writing %s: %v\n", out, err)
+		return 1
+	}
+	fmt.Fprintf(e.stdout, "Signed %s -> %s (key id %s)\n", file, out, keyID(pub))
+	return 0
+}
+
+func verifyFile(e *environment, pubPath, file, sigPath string, isManifest bool) int {
+	pub, err := readPublicKey(e.fs, pubPath)
+	if err != nil {
+		fmt.Fprintf(e.stderr, "Error reading key %s: %v\n", pubPath, err)
+		return 1
+	}
+	data, err := e.fs.ReadFile(file)
+	if err != nil {
+		fmt.Fprintf(e.stderr, "Error reading %s: %v\n", file, err)
+		return 1
+	}
+	sig, err := e.fs.ReadFile(sigPath)
+	if err != nil {
+		fmt.Fprintf(e.stderr, "Error reading %s: %v\n", sigPath, err)
+		return 1
+	}
+
+	if err := checkSignature(pub, data, sig); err != nil {
+		fmt.Fprintf(e.stdout, "FAIL %s: %v\n", file, err)
+		return 1
+	}
+	fmt.Fprintf(e.stdout, "OK %s (key id %s)\n", file, keyID(pub))
+	if !isManifest {
+		return 0
+	}
+
+	m, err := manifest.Parse(data)
+	if err != nil {
+		fmt.Fprintf(e.stderr, "Error reading %s: %v\n", file, err)
+		return 1
+	}
+	// A validly signed manifest can still be malformed, e.g. list no
+	// versions or a dangling latest; check the schema before the files.
+	if err := m.Validate(); err != nil {
+		fmt.Fprintf(e.stdout, "FAIL %s: invalid manifest\n", file)
+		for _, line := range strings.Split(err.Error(), "\n") {
+			fmt.Fprintf(e.stdout, "  %s\n", line)
+		}
+		return 1
+	}
+	return verifyManifestFiles(e, path.Dir(file), m)
+}
+
+// verifyManifestFiles checks each version's wasm file, which lives next to
+// the manifest, against the size and sha256 the manifest records.
+func verifyManifestFiles(e *environment, dir string, m *manifest.Runtime) int {
+	versions := make([]string, 0, len(m.Versions))
+	for v := range m.Versions {
+		versions = append(versions, v)
+	}
+	sort.Strings(versions)
+
+	code := 0
+	for _, v := range versions {
+		entry := m.Versions[v]
+		if entry.File == "." || entry.File == ".." || strings.ContainsAny(entry.File, `/\`) {
+			fmt.Fprintf(e.stdout, "FAIL %s (%s %s): file must be a bare file name\n", entry.File, m.Language, v)
+			code = 1
+			continue
+		}
+		p := path.Join(dir, entry.File)
+		data, err := e.fs.ReadFile(p)
+		switch {
+		case errors.Is(err, fs.ErrNotExist):
+			fmt.Fprintf(e.stdout, "FAIL %s (%s %s): file not found\n", p, m.Language, v)
+			code = 1
+		case err != nil:
+			fmt.Fprintf(e.stdout, "FAIL %s (%s %s): %v\n", p, m.Language, v, err)
+			code = 1
+		case int64(len(data)) != entry.Size:
+			fmt.Fprintf(e.stdout, "FAIL %s (%s %s): size %d, manifest has %d\n", p, m.Language, v, len(data), entry.Size)
+			code = 1
+		default:
+			sum := sha256.Sum256(data)
+			if got := hex.EncodeToString(sum[:]); got != strings.ToLower(entry.SHA256) {
+				fmt.Fprintf(e.stdout, "FAIL %s (%s %s): sha256 %s, manifest has %s\n", p, m.Language, v, got, entry.SHA256)
+				code = 1
+				continue
+			}
+			fmt.Fprintf(e.stdout, "OK %s (%s %s)\n", p, m.Language, v)
+		}
+	}
+	return code
+}
+
+func checkSignature(pub ed25519.PublicKey, data, sigFile []byte) error {
+	fields := strings.Fields(string(sigFile))
+	if len(fields) != 3 || fields[0] != signatureTag {
+		return errors.New("malformed signature file")
+	}
+	if fields[1] != keyID(pub) {
+		return fmt.Errorf("%w (key id %s)", errWrongKey, fields[1])
+	}
+	sig, err := base64.StdEncoding.DecodeString(fields[2])
+	if err != nil || len(sig) != ed25519.SignatureSize {
+		return errors.New("malformed signature file")
+	}
+	if !ed25519.Verify(pub, data, sig) {
+		return errors.New("signature does not match content")
+	}
+	return nil
+}
+
+func keyID(pub ed25519.PublicKey) string {
+	sum := sha256.Sum256(pub)
+	return hex.EncodeToString(sum[:8])
+}
+
+func encodeKeyLine(tag string, key []byte) []byte {
+	return []byte(tag + " " + base64.StdEncoding.EncodeToString(key) + "\n")
+}
+
+func readKeyLine(fsys fileSystem, name, tag string, size int) ([]byte, error) {
+	data, err := fsys.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	fields := strings.Fields(string(data))
+	if len(fields) != 2 || fields[0] != tag {
+		return nil, fmt.Errorf("not an %s key file", tag)
+	}
+	key, err := base64.StdEncoding.DecodeString(fields[1])
+	if err != nil || len(key) != size {
+		return nil, fmt.Errorf("malformed %s key", tag)
+	}
+	return key, nil
+}
+
+func readPrivateKey(fsys fileSystem, name string) (ed25519.PrivateKey, error) {
+	seed, err := readKeyLine(fsys, name, privateKeyTag, ed25519.SeedSize)
+	if err != nil {
+		return nil, err
+	}
+	return ed25519.NewKeyFromSeed(seed), nil
+}
+
+func readPublicKey(fsys fileSystem, name string) (ed25519.PublicKey, error) {
+	key, err := readKeyLine(fsys, name, publicKeyTag, ed25519.PublicKeySize)
+	if err != nil {
+		return nil, err
+	}
+	return ed25519.PublicKey(key), nil
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+// signedFS returns a filesystem holding a key pair, a wasm file and a
+// signed manifest that references it.
+func signedFS(t *testing.T) *memFS {
+	t.Helper()
+	fsys := newTestFS()
+	wasm := []byte("\x00asm\x01\x00\x00\x00")
+	sum := sha256.Sum256(wasm)
+	fsys.files["rt/go-1.22.wasm"] = &fstest.MapFile{Data: wasm}
+	fsys.files["rt/go-1.23.wasm"] = &fstest.MapFile{Data: wasm}
+	fsys.files["rt/manifest.json"] = &fstest.MapFile{Data: []byte(fmt.Sprintf(`{
+    "language": "go",
+    "latest": "1.23",
+    "versions": {
+        "1.22": {"file": "go-1.22.wasm", "size": 8, "sha256": "%[1]s", "released": "2026-01-02T03:04:05Z", "wasi": "wasip1", "features": []},
+        "1.23": {"file": "go-1.23.wasm", "size": 8, "sha256": "%[1]s", "released": "2026-01-02T03:04:05Z", "wasi": "wasip1", "features": []}
+    }
+}
+`, hex.EncodeToString(sum[:])))}
+
+	for _, args := range [][]string{
+		{"keygen", "release"},
+		{"sign", "--key", "release.key", "hello.txt"},
+		{"sign", "--key", "release.key", "rt/manifest.json"},
+	} {
+		if got := transcript(fsys, args...); !strings.HasPrefix(got, "exit: 0\n") {
+			t.Fatalf("%v failed:\n%s", args, got)
+		}
+	}
+	return fsys
+}
+
+// resignManifest replaces rt/manifest.json and signs the new content, so
+// verification gets past the signature to the manifest checks.
+func resignManifest(t *testing.T, fsys *memFS, data string) {
+	t.Helper()
+	fsys.files["rt/manifest.json"] = &fstest.MapFile{Data: []byte(data)}
+	if got := transcript(fsys, "sign", "--key", "release.key", "rt/manifest.json"); !strings.HasPrefix(got, "exit: 0\n") {
+		t.Fatalf("re-signing manifest failed:\n%s", got)
+	}
+}
+
+func TestSignGolden(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup func(fsys *memFS)
+		args  []string
+	}{
+		{"keygen", nil, []string{"keygen", "other"}},
+		{"keygen_exists", nil, []string{"keygen", "release"}},
+		{"sign", nil, []string{"sign", "--key", "release.key", "--out", "hello.sig", "hello.txt"}},
+		{"sign_missing_key_flag", nil, []string{"sign", "hello.txt"}},
+		{"sign_wrong_key_type", nil, []string{"sign", "--key", "release.pub", "hello.txt"}},
+		{"verify", nil, []string{"verify", "--pub", "release.pub", "hello.txt"}},
+		{
+			"verify_tampered",
+			func(fsys *memFS) { fsys.files["hello.txt"] = &fstest.MapFile{Data: []byte("Hello, attacker!\n")} },
+			[]string{"verify", "--pub", "release.pub", "hello.txt"},
+		},
+		{
+			"verify_wrong_key",
+			func(fsys *memFS) {
+				other := ed25519.NewKeyFromSeed(bytes.Repeat([]byte{7}, ed25519.SeedSize))
+				fsys.files["other.pub"] = &fstest.MapFile{Data: encodeKeyLine(publicKeyTag, other.Public().(ed25519.PublicKey))}
+			},
+			[]string{"verify", "--pub", "other.pub", "hello.txt"},
+		},
+		{
+			"verify_malformed_signature",
+			func(fsys *memFS) { fsys.files["hello.txt.sig"] = &fstest.MapFile{Data: []byte("not a signature\n")} },
+			[]string{"verify", "--pub", "release.pub", "hello.txt"},
+		},
+		{"verify_missing_signature", nil, []string{"verify", "--pub", "release.pub", "empty.txt"}},
+		{"verify_manifest", nil, []string{"verify", "--pub", "release.pub", "--manifest", "rt/manifest.json"}},
+		{
+			"verify_manifest_missing_wasm",
+			func(fsys *memFS) { delete(fsys.files, "rt/go-1.22.wasm") },
+			[]string{"verify", "--pub", "release.pub", "--manifest", "rt/manifest.json"},
+		},
+		{
+			"verify_manifest_no_versions",
+			func(fsys *memFS) { resignManifest(t, fsys, `{"language": "go", "latest": "", "versions": {}}`) },
+			[]string{"verify", "--pub", "release.pub", "--manifest", "rt/manifest.json"},
+		},
+		{
+			"verify_manifest_dangling_latest",
+			func(fsys *memFS) {
+				data := strings.Replace(string(fsys.files["rt/manifest.json"].Data), `"latest": "1.23"`, `"latest": "1.24"`, 1)
+				resignManifest(t, fsys, data)
+			},
+			[]string{"verify", "--pub", "release.pub", "--manifest", "rt/manifest.json"},
+		},
+		{
+			"verify_manifest_parent_path",
+			func(fsys *memFS) {
+				data := strings.Replace(string(fsys.files["rt/manifest.json"].Data), `"go-1.22.wasm"`, `".."`, 1)
+				resignManifest(t, fsys, data)
+			},
+			[]string{"verify", "--pub", "release.pub", "--manifest", "rt/manifest.json"},
+		},
+		{
+			"verify_manifest_size_mismatch",
+			func(fsys *memFS) {
+				fsys.files["rt/go-1.22.wasm"] = &fstest.MapFile{Data: []byte("\x00asm\x01\x00\x00\x00\x00")}
+			},
+			[]string{"verify", "--pub", "release.pub", "--manifest", "rt/manifest.json"},
+		},
+		{
+			"verify_manifest_bad_wasm",
+			func(fsys *memFS) {
+				fsys.files["rt/go-1.22.wasm"] = &fstest.MapFile{Data: []byte("\x00asm\x01\x00\x00\x01")}
+			},
+			[]string{"verify", "--pub", "release.pub", "--manifest", "rt/manifest.json"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fsys := signedFS(t)
+			if tt.setup != nil {
+				tt.setup(fsys)
+			}
+			checkGolden(t, tt.name, transcript(fsys, tt.args...))
+		})
+	}
+}
+
+func TestKeygenUsesRandomness(t *testing.T) {
+	fsys := newTestFS()
+	transcript(fsys, "keygen", "k")
+	key, err := fsys.ReadFile("k.key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The test environment's rand yields 0x42 bytes, so the seed is fixed.
+	want := "ed25519-private QkJCQkJCQkJCQkJCQkJCQkJCQkJCQkJCQkJCQkJCQkI=\n"
+	if string(key) != want {
+		t.Errorf("k.key = %q, want %q", key, want)
+	}
+}
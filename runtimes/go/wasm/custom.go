@@ -0,0 +1,57 @@
+package wasm
+
+import "bytes"
+
+// SetCustomSection returns a copy of the binary module data with every
+// custom section called name removed and a new one holding payload
+// appended at the end.
+func SetCustomSection(data []byte, name string, payload []byte) ([]byte, error) {
+	if _, err := Parse(data); err != nil {
+		return nil, err
+	}
+
+	out := bytes.NewBuffer(make([]byte, 0, len(data)+len(name)+len(payload)+16))
+	out.Write(data[:8])
+	r := &reader{data: data, pos: 8}
+	for !r.eof() {
+		start := r.pos
+		id, _ := r.byte()
+		size, _ := r.count()
+		body, _ := r.bytes(size)
+		if id == SectionCustom {
+			if n, err := (&reader{data: body}).name(); err == nil && n == name {
+				continue
+			}
+		}
+		out.Write(data[start:r.pos])
+	}
+
+	contents := append(appendULEB(nil, uint64(len(name))), name...)
+	contents = append(contents, payload...)
+	out.WriteByte(SectionCustom)
+	out.Write(appendULEB(nil, uint64(len(contents))))
+	out.Write(contents)
+	return out.Bytes(), nil
+}
+
+// CustomSection returns the contents of the first custom section called
+// name.
+func (m *Module) CustomSection(name string) ([]byte, bool) {
+	for _, c := range m.Customs {
+		if c.Name == name {
+			return c.Data, true
+		}
+	}
+	return nil, false
+}
+
+func appendULEB(b []byte, v uint64) []byte {
+	for {
+		c := byte(v & 0x7f)
+		v >>= 7
+		if v == 0 {
+			return append(b, c)
+		}
+		b = append(b, c|0x80)
+	}
+}
@@ -0,0 +1,39 @@
+package wasm
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestSetCustomSection(t *testing.T) {
+	data, err := SetCustomSection(testModule(), "producers", []byte("v1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err = SetCustomSection(data, "producers", []byte("v2"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := Parse(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+	for _, c := range m.Customs {
+		names = append(names, c.Name)
+	}
+	if len(names) != 2 || names[0] != "name" || names[1] != "producers" {
+		t.Errorf("custom sections = %q, want [name producers]", names)
+	}
+	if got, ok := m.CustomSection("producers"); !ok || !bytes.Equal(got, []byte("v2")) {
+		t.Errorf("producers = %q, %v; want v2", got, ok)
+	}
+	if m.FuncName(1) != "_start" || len(m.Code) != 2 {
+		t.Error("rewriting a custom section lost other sections")
+	}
+
+	if _, err := SetCustomSection([]byte("not wasm"), "x", nil); err == nil {
+		t.Error("SetCustomSection accepted an invalid module")
+	}
+}
@@ -0,0 +1,96 @@
+package wasm
+
+import "strings"
+
+// WASIModule is the import module name of WASI preview 1.
+const WASIModule = "wasi_snapshot_preview1"
+
+// Capability names recorded in a runtime manifest's features array, in the
+// order Features reports them.
+var featureOrder = []string{
+	"args",
+	"env",
+	"stdio",
+	"filesystem",
+	"clock",
+	"random",
+	"poll",
+	"sockets",
+	"proc",
+}
+
+// wasiFeatures maps WASI preview 1 functions to the capability they need.
+// Functions prefixed "path_" or "sock_" are matched by prefix in
+// wasiFeature.
+var wasiFeatures = map[string]string{
+	"args_get":       "args",
+	"args_sizes_get": "args",
+
+	"environ_get":       "env",
+	"environ_sizes_get": "env",
+
+	"fd_read":  "stdio",
+	"fd_write": "stdio",
+
+	"fd_prestat_get":        "filesystem",
+	"fd_prestat_dir_name":   "filesystem",
+	"fd_readdir":            "filesystem",
+	"fd_filestat_get":       "filesystem",
+	"fd_filestat_set_size":  "filesystem",
+	"fd_filestat_set_times": "filesystem",
+	"fd_pread":              "filesystem",
+	"fd_pwrite":             "filesystem",
+	"fd_seek":               "filesystem",
+	"fd_tell":               "filesystem",
+	"fd_sync":               "filesystem",
+	"fd_datasync":           "filesystem",
+	"fd_allocate":           "filesystem",
+	"fd_advise":             "filesystem",
+	"fd_renumber":           "filesystem",
+	"fd_fdstat_set_rights":  "filesystem",
+
+	"clock_time_get": "clock",
+	"clock_res_get":  "clock",
+
+	"random_get": "random",
+
+	"poll_oneoff": "poll",
+	"sched_yield": "poll",
+
+	"proc_exit":  "proc",
+	"proc_raise": "proc",
+}
+
+func wasiFeature(name string) (string, bool) {
+	switch {
+	case strings.HasPrefix(name, "path_"):
+		return "filesystem", true
+	case strings.HasPrefix(name, "sock_"):
+		return "sockets", true
+	}
+	f, ok := wasiFeatures[name]
+	return f, ok
+}
+
+// Features derives the capabilities a module needs from its WASI preview 1
+// function imports. Functions every program uses for bookkeeping, such as
+// fd_close and fd_fdstat_get, map to no feature, nor do imports from other
+// modules.
+func Features(m *Module) []string {
+	seen := make(map[string]bool)
+	for _, imp := range m.Imports {
+		if imp.Kind != KindFunc || imp.Module != WASIModule {
+			continue
+		}
+		if f, ok := wasiFeature(imp.Name); ok {
+			seen[f] = true
+		}
+	}
+	features := []string{}
+	for _, f := range featureOrder {
+		if seen[f] {
+			features = append(features, f)
+		}
+	}
+	return features
+}
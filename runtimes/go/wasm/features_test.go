@@ -0,0 +1,30 @@
+package wasm
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFeatures(t *testing.T) {
+	wasi := func(name string) Import { return Import{Module: WASIModule, Name: name, Kind: KindFunc} }
+	m := &Module{Imports: []Import{
+		wasi("sock_accept"),
+		wasi("fd_write"),
+		wasi("fd_close"),
+		wasi("path_open"),
+		wasi("random_get"),
+		wasi("clock_time_get"),
+		wasi("poll_oneoff"),
+		wasi("args_get"),
+		wasi("proc_exit"),
+		{Module: "env", Name: "environ_get", Kind: KindFunc},
+		{Module: WASIModule, Name: "environ_get", Kind: KindGlobal},
+	}}
+	want := []string{"args", "stdio", "filesystem", "clock", "random", "poll", "sockets", "proc"}
+	if got := Features(m); !reflect.DeepEqual(got, want) {
+		t.Errorf("Features = %q, want %q", got, want)
+	}
+	if got := Features(&Module{}); got == nil || len(got) != 0 {
+		t.Errorf("Features of an empty module = %#v, want []", got)
+	}
+}
@@ -0,0 +1,571 @@
+// Package wasm parses WebAssembly binary modules. It decodes the module
+// structure (types, imports, exports, limits, custom sections and function
+// bodies) and validates the header and section layout, but does not type
+// check instructions.
+package wasm
+
+import (
+	"bytes"
+	"fmt"
+)
+
+var magic = []byte("\x00asm")
+
+// Section ids in the order they must appear. Custom sections (0) may
+// appear anywhere, and the data count section (12) sits between the
+// element and code sections.
+const (
+	SectionCustom    byte = 0
+	SectionType      byte = 1
+	SectionImport    byte = 2
+	SectionFunction  byte = 3
+	SectionTable     byte = 4
+	SectionMemory    byte = 5
+	SectionGlobal    byte = 6
+	SectionExport    byte = 7
+	SectionStart     byte = 8
+	SectionElement   byte = 9
+	SectionCode      byte = 10
+	SectionData      byte = 11
+	SectionDataCount byte = 12
+	SectionTag       byte = 13
+)
+
+var sectionNames = map[byte]string{
+	SectionCustom:    "custom",
+	SectionType:      "type",
+	SectionImport:    "import",
+	SectionFunction:  "function",
+	SectionTable:     "table",
+	SectionMemory:    "memory",
+	SectionGlobal:    "global",
+	SectionExport:    "export",
+	SectionStart:     "start",
+	SectionElement:   "element",
+	SectionCode:      "code",
+	SectionData:      "data",
+	SectionDataCount: "datacount",
+	SectionTag:       "tag",
+}
+
+// sectionOrder ranks non-custom sections by their required position.
+var sectionOrder = map[byte]int{
+	SectionType:      1,
+	SectionImport:    2,
+	SectionFunction:  3,
+	SectionTable:     4,
+	SectionMemory:    5,
+	SectionTag:       6,
+	SectionGlobal:    7,
+	SectionExport:    8,
+	SectionStart:     9,
+	SectionElement:   10,
+	SectionDataCount: 11,
+	SectionCode:      12,
+	SectionData:      13,
+}
+
+// Section records where a section sits in the file. Offset and Size cover
+// the section contents, excluding the id byte and size field.
+type Section struct {
+	ID     byte
+	Name   string // "custom:<name>" for custom sections
+	Offset int
+	Size   int
+}
+
+// Module is a decoded WebAssembly module.
+type Module struct {
+	Version   uint32
+	Sections  []Section
+	Types     []FuncType
+	Imports   []Import
+	Functions []uint32 // type index of each defined function
+	Tables    []Table
+	Memories  []Limits
+	Tags      []uint32 // type index of each defined tag
+	Globals   []Global
+	Exports   []Export
+	Start     *uint32
+	Elements  int
+	DataCount *uint32
+	Code      []Code
+	Data      int
+	Customs   []CustomSection
+	Names     Names
+}
+
+// SectionName returns the conventional name of a section id.
+func SectionName(id byte) string {
+	if name, ok := sectionNames[id]; ok {
+		return name
+	}
+	return fmt.Sprintf("unknown(%d)", id)
+}
+
+// Parse decodes and structurally validates a binary module.
+func Parse(data []byte) (*Module, error) {
+	if len(data) < 8 || !bytes.Equal(data[:4], magic) {
+		return nil, &ParseError{Offset: 0, Msg: "missing \\0asm magic number"}
+	}
+	m := &Module{Version: uint32(data[4]) | uint32(data[5])<<8 | uint32(data[6])<<16 | uint32(data[7])<<24}
+	if m.Version != 1 {
+		return nil, &ParseError{Offset: 4, Msg: fmt.Sprintf("unsupported version %d", m.Version)}
+	}
+
+	r := &reader{data: data, pos: 8}
+	last := 0
+	for !r.eof() {
+		start := r.offset()
+		id, err := r.byte()
+		if err != nil {
+			return nil, err
+		}
+		size, err := r.count()
+		if err != nil {
+			return nil, err
+		}
+		body, _ := r.bytes(size)
+		sec := &reader{data: body, base: r.offset() - size}
+
+		if id != SectionCustom {
+			order, ok := sectionOrder[id]
+			if !ok {
+				return nil, &ParseError{Offset: start, Msg: fmt.Sprintf("unknown section id %d", id)}
+			}
+			if order <= last {
+				return nil, &ParseError{Offset: start, Msg: fmt.Sprintf("%s section out of order or duplicated", SectionName(id))}
+			}
+			last = order
+		}
+
+		name, err := m.parseSection(id, sec)
+		if err != nil {
+			return nil, err
+		}
+		if !sec.eof() {
+			return nil, sec.errorf("%s section has %d trailing bytes", SectionName(id), len(body)-sec.pos)
+		}
+		m.Sections = append(m.Sections, Section{ID: id, Name: name, Offset: sec.base, Size: size})
+	}
+
+	if len(m.Functions) != len(m.Code) {
+		return nil, &ParseError{Offset: len(data), Msg: fmt.Sprintf("function section declares %d functions but code section has %d bodies", len(m.Functions), len(m.Code))}
+	}
+	if m.DataCount != nil && int(*m.DataCount) != m.Data {
+		return nil, &ParseError{Offset: len(data), Msg: fmt.Sprintf("data count %d does not match %d data segments", *m.DataCount, m.Data)}
+	}
+	return m, nil
+}
+
+func (m *Module) parseSection(id byte, r *reader) (string, error) {
+	var err error
+	switch id {
+	case SectionCustom:
+		var name string
+		if name, err = r.name(); err != nil {
+			return "", err
+		}
+		data := r.data[r.pos:]
+		r.pos = len(r.data)
+		m.Customs = append(m.Customs, CustomSection{Name: name, Data: data})
+		if name == "name" {
+			// A malformed name section is not fatal; it only loses names.
+			m.Names, _ = parseNames(&reader{data: data, base: r.base + len(r.data) - len(data)})
+		}
+		return "custom:" + name, nil
+	case SectionType:
+		err = vec(r, func() error {
+			t, err := readFuncType(r)
+			m.Types = append(m.Types, t)
+			return err
+		})
+	case SectionImport:
+		err = vec(r, func() error {
+			imp, err := readImport(r)
+			m.Imports = append(m.Imports, imp)
+			return err
+		})
+	case SectionFunction:
+		err = vec(r, func() error {
+			idx, err := r.u32()
+			m.Functions = append(m.Functions, idx)
+			return err
+		})
+	case SectionTable:
+		err = vec(r, func() error {
+			t, err := readTable(r)
+			m.Tables = append(m.Tables, t)
+			return err
+		})
+	case SectionMemory:
+		err = vec(r, func() error {
+			l, err := readLimits(r)
+			m.Memories = append(m.Memories, l)
+			return err
+		})
+	case SectionTag:
+		err = vec(r, func() error {
+			idx, err := readTag(r)
+			m.Tags = append(m.Tags, idx)
+			return err
+		})
+	case SectionGlobal:
+		err = vec(r, func() error {
+			g, err := readGlobal(r)
+			m.Globals = append(m.Globals, g)
+			return err
+		})
+	case SectionExport:
+		err = vec(r, func() error {
+			e, err := readExport(r)
+			m.Exports = append(m.Exports, e)
+			return err
+		})
+	case SectionStart:
+		var idx uint32
+		idx, err = r.u32()
+		m.Start = &idx
+	case SectionElement:
+		err = vec(r, func() error {
+			m.Elements++
+			return skipElement(r)
+		})
+	case SectionDataCount:
+		var n uint32
+		n, err = r.u32()
+		m.DataCount = &n
+	case SectionCode:
+		err = vec(r, func() error {
+			c, err := readCode(r)
+			m.Code = append(m.Code, c)
+			return err
+		})
+	case SectionData:
+		err = vec(r, func() error {
+			m.Data++
+			return skipData(r)
+		})
+	}
+	return SectionName(id), err
+}
+
+// vec reads a vector length and calls elem once per element.
+func vec(r *reader, elem func() error) error {
+	n, err := r.count()
+	if err != nil {
+		return err
+	}
+	for range n {
+		if err := elem(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func readValType(r *reader) (ValType, error) {
+	b, err := r.byte()
+	if err != nil {
+		return 0, err
+	}
+	if t := ValType(b); t.valid() {
+		return t, nil
+	}
+	r.pos--
+	return 0, r.errorf("invalid value type 0x%02x", b)
+}
+
+func readValTypes(r *reader) ([]ValType, error) {
+	var types []ValType
+	err := vec(r, func() error {
+		t, err := readValType(r)
+		types = append(types, t)
+		return err
+	})
+	return types, err
+}
+
+func readFuncType(r *reader) (FuncType, error) {
+	if b, err := r.byte(); err != nil {
+		return FuncType{}, err
+	} else if b != 0x60 {
+		r.pos--
+		return FuncType{}, r.errorf("expected function type 0x60, got 0x%02x", b)
+	}
+	params, err := readValTypes(r)
+	if err != nil {
+		return FuncType{}, err
+	}
+	results, err := readValTypes(r)
+	return FuncType{Params: params, Results: results}, err
+}
+
+func readLimits(r *reader) (Limits, error) {
+	flags, err := r.byte()
+	if err != nil {
+		return Limits{}, err
+	}
+	if flags > 7 {
+		r.pos--
+		return Limits{}, r.errorf("invalid limits flags 0x%02x", flags)
+	}
+	l := Limits{Shared: flags&2 != 0, Is64: flags&4 != 0}
+	bits := uint(32)
+	if l.Is64 {
+		bits = 64
+	}
+	if l.Min, err = r.uleb(bits); err != nil {
+		return l, err
+	}
+	if flags&1 != 0 {
+		max, err := r.uleb(bits)
+		if err != nil {
+			return l, err
+		}
+		l.Max = &max
+	}
+	return l, nil
+}
+
+func readTable(r *reader) (Table, error) {
+	elem, err := readValType(r)
+	if err != nil {
+		return Table{}, err
+	}
+	limits, err := readLimits(r)
+	return Table{Elem: elem, Limits: limits}, err
+}
+
+func readGlobalType(r *reader) (GlobalType, error) {
+	t, err := readValType(r)
+	if err != nil {
+		return GlobalType{}, err
+	}
+	mut, err := r.byte()
+	if err != nil {
+		return GlobalType{}, err
+	}
+	if mut > 1 {
+		r.pos--
+		return GlobalType{}, r.errorf("invalid mutability 0x%02x", mut)
+	}
+	return GlobalType{Type: t, Mutable: mut == 1}, nil
+}
+
+func readTag(r *reader) (uint32, error) {
+	if attr, err := r.byte(); err != nil {
+		return 0, err
+	} else if attr != 0 {
+		r.pos--
+		return 0, r.errorf("invalid tag attribute 0x%02x", attr)
+	}
+	return r.u32()
+}
+
+func readImport(r *reader) (Import, error) {
+	var imp Import
+	var err error
+	if imp.Module, err = r.name(); err != nil {
+		return imp, err
+	}
+	if imp.Name, err = r.name(); err != nil {
+		return imp, err
+	}
+	kind, err := r.byte()
+	if err != nil {
+		return imp, err
+	}
+	imp.Kind = ExternKind(kind)
+	switch imp.Kind {
+	case KindFunc:
+		imp.Type, err = r.u32()
+	case KindTable:
+		imp.Table, err = readTable(r)
+	case KindMemory:
+		imp.Memory, err = readLimits(r)
+	case KindGlobal:
+		imp.Global, err = readGlobalType(r)
+	case KindTag:
+		imp.Type, err = readTag(r)
+	default:
+		r.pos--
+		err = r.errorf("invalid import kind 0x%02x", kind)
+	}
+	return imp, err
+}
+
+func readExport(r *reader) (Export, error) {
+	var e Export
+	var err error
+	if e.Name, err = r.name(); err != nil {
+		return e, err
+	}
+	kind, err := r.byte()
+	if err != nil {
+		return e, err
+	}
+	if kind > byte(KindTag) {
+		r.pos--
+		return e, r.errorf("invalid export kind 0x%02x", kind)
+	}
+	e.Kind = ExternKind(kind)
+	e.Index, err = r.u32()
+	return e, err
+}
+
+func readGlobal(r *reader) (Global, error) {
+	t, err := readGlobalType(r)
+	if err != nil {
+		return Global{}, err
+	}
+	start := r.pos
+	if err := skipConstExpr(r); err != nil {
+		return Global{}, err
+	}
+	return Global{Type: t, Init: r.data[start : r.pos-1]}, nil
+}
+
+// skipConstExpr advances past a constant expression and its end opcode.
+// Besides the MVP constants it accepts the extended-const arithmetic
+// opcodes, which carry no immediates.
+func skipConstExpr(r *reader) error {
+	for {
+		op, err := r.byte()
+		if err != nil {
+			return err
+		}
+		switch op {
+		case 0x0b: // end
+			return nil
+		case 0x41: // i32.const
+			_, err = r.sleb(32)
+		case 0x42: // i64.const
+			_, err = r.sleb(64)
+		case 0x43: // f32.const
+			_, err = r.bytes(4)
+		case 0x44: // f64.const
+			_, err = r.bytes(8)
+		case 0x23, 0xd2: // global.get, ref.func
+			_, err = r.u32()
+		case 0xd0: // ref.null
+			_, err = readValType(r)
+		case 0x6a, 0x6b, 0x6c, 0x7c, 0x7d, 0x7e: // i32/i64 add, sub, mul
+		case 0xfd: // v128.const
+			if sub, err := r.u32(); err != nil {
+				return err
+			} else if sub != 12 {
+				return r.errorf("unsupported SIMD opcode 0x%x in constant expression", sub)
+			}
+			_, err = r.bytes(16)
+		default:
+			r.pos--
+			return r.errorf("unsupported opcode 0x%02x in constant expression", op)
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// skipElement advances past an element segment in any of its eight
+// encodings.
+func skipElement(r *reader) error {
+	flags, err := r.u32()
+	if err != nil {
+		return err
+	}
+	if flags > 7 {
+		return r.errorf("invalid element segment flags %d", flags)
+	}
+	passiveOrDeclarative := flags&1 != 0
+	explicitTable := flags&2 != 0
+	usesExprs := flags&4 != 0
+
+	if !passiveOrDeclarative {
+		if explicitTable {
+			if _, err := r.u32(); err != nil {
+				return err
+			}
+		}
+		if err := skipConstExpr(r); err != nil {
+			return err
+		}
+	}
+	if passiveOrDeclarative || explicitTable {
+		// elemkind (0x00) or reftype
+		if _, err := r.byte(); err != nil {
+			return err
+		}
+	}
+	return vec(r, func() error {
+		if usesExprs {
+			return skipConstExpr(r)
+		}
+		_, err := r.u32()
+		return err
+	})
+}
+
+func skipData(r *reader) error {
+	flags, err := r.u32()
+	if err != nil {
+		return err
+	}
+	switch flags {
+	case 0:
+		err = skipConstExpr(r)
+	case 1:
+	case 2:
+		if _, err = r.u32(); err == nil {
+			err = skipConstExpr(r)
+		}
+	default:
+		return r.errorf("invalid data segment flags %d", flags)
+	}
+	if err != nil {
+		return err
+	}
+	n, err := r.count()
+	if err != nil {
+		return err
+	}
+	_, err = r.bytes(n)
+	return err
+}
+
+func readCode(r *reader) (Code, error) {
+	size, err := r.count()
+	if err != nil {
+		return Code{}, err
+	}
+	body, _ := r.bytes(size)
+	br := &reader{data: body, base: r.offset() - size}
+
+	var c Code
+	var total uint64
+	err = vec(br, func() error {
+		n, err := br.u32()
+		if err != nil {
+			return err
+		}
+		t, err := readValType(br)
+		if err != nil {
+			return err
+		}
+		if total += uint64(n); total > 50000 {
+			return br.errorf("too many locals")
+		}
+		c.Locals = append(c.Locals, Local{Count: n, Type: t})
+		return nil
+	})
+	if err != nil {
+		return c, err
+	}
+	c.Body = body[br.pos:]
+	c.Offset = br.offset()
+	if len(c.Body) == 0 || c.Body[len(c.Body)-1] != 0x0b {
+		return c, br.errorf("function body does not end with the end opcode")
+	}
+	return c, nil
+}
@@ -0,0 +1,217 @@
+package wasm
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// Helpers for assembling binary modules by hand.
+
+func uleb(v uint64) []byte {
+	var b []byte
+	for {
+		c := byte(v & 0x7f)
+		v >>= 7
+		if v != 0 {
+			c |= 0x80
+		}
+		b = append(b, c)
+		if v == 0 {
+			return b
+		}
+	}
+}
+
+func str(s string) []byte { return append(uleb(uint64(len(s))), s...) }
+
+func cat(parts ...[]byte) []byte {
+	var b []byte
+	for _, p := range parts {
+		b = append(b, p...)
+	}
+	return b
+}
+
+func vector(items ...[]byte) []byte { return cat(uleb(uint64(len(items))), cat(items...)) }
+
+func section(id byte, contents ...[]byte) []byte {
+	body := cat(contents...)
+	return cat([]byte{id}, uleb(uint64(len(body))), body)
+}
+
+func module(sections ...[]byte) []byte {
+	return cat([]byte("\x00asm\x01\x00\x00\x00"), cat(sections...))
+}
+
+// testModule imports fd_write and a memory, defines two functions (one
+// with a multi-value result), exports them, and names them.
+func testModule() []byte {
+	fdWrite := []byte{0x60, 4, 0x7f, 0x7f, 0x7f, 0x7f, 1, 0x7f}
+	start := []byte{0x60, 0, 0}
+	pair := []byte{0x60, 1, 0x7e, 2, 0x7e, 0x7d}
+	return module(
+		section(SectionType, vector(fdWrite, start, pair)),
+		section(SectionImport, vector(
+			cat(str("wasi_snapshot_preview1"), str("fd_write"), []byte{0x00, 0}),
+			cat(str("env"), str("memory"), []byte{0x02, 0x01, 2, 16}),
+		)),
+		section(SectionFunction, vector([]byte{1}, []byte{2})),
+		section(SectionGlobal, vector(cat([]byte{0x7f, 1, 0x41}, uleb(1024), []byte{0x0b}))),
+		section(SectionExport, vector(
+			cat(str("_start"), []byte{0x00, 1}),
+			cat(str("memory"), []byte{0x02, 0}),
+		)),
+		section(SectionCode, vector(
+			str("\x00\x0b"),
+			str("\x01\x01\x7f\x20\x00\x43\x00\x00\x80\x3f\x0b"),
+		)),
+		section(SectionData, vector(cat([]byte{0, 0x41, 8, 0x0b}, str("hi")))),
+		section(SectionCustom, str("name"), section(1, vector(
+			cat(uleb(1), str("_start")),
+			cat(uleb(2), str("pair")),
+		))),
+		section(SectionCustom, str("producers"), []byte{0}),
+	)
+}
+
+func TestParse(t *testing.T) {
+	m, err := Parse(testModule())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var types []string
+	for _, ft := range m.Types {
+		types = append(types, ft.String())
+	}
+	wantTypes := []string{"(i32, i32, i32, i32) -> i32", "() -> ()", "(i64) -> (i64, f32)"}
+	if !reflect.DeepEqual(types, wantTypes) {
+		t.Errorf("types = %q, want %q", types, wantTypes)
+	}
+
+	if len(m.Imports) != 2 {
+		t.Fatalf("got %d imports, want 2", len(m.Imports))
+	}
+	if imp := m.Imports[0]; imp.Module != "wasi_snapshot_preview1" || imp.Name != "fd_write" || imp.Kind != KindFunc || imp.Type != 0 {
+		t.Errorf("import 0 = %+v", imp)
+	}
+	if mem := m.Imports[1].Memory; m.Imports[1].Kind != KindMemory || mem.String() != "min 2, max 16" {
+		t.Errorf("import 1 = %+v", m.Imports[1])
+	}
+
+	wantExports := []Export{{"_start", KindFunc, 1}, {"memory", KindMemory, 0}}
+	if !reflect.DeepEqual(m.Exports, wantExports) {
+		t.Errorf("exports = %+v, want %+v", m.Exports, wantExports)
+	}
+	if m.ImportedFuncs() != 1 || len(m.Code) != 2 || m.Data != 1 {
+		t.Errorf("imported funcs %d, code %d, data %d", m.ImportedFuncs(), len(m.Code), m.Data)
+	}
+	if got := m.Globals[0].Type.String(); got != "mut i32" {
+		t.Errorf("global type = %s, want mut i32", got)
+	}
+	if want := []Local{{1, I32}}; !reflect.DeepEqual(m.Code[1].Locals, want) {
+		t.Errorf("locals = %+v, want %+v", m.Code[1].Locals, want)
+	}
+	if ft, ok := m.FuncType(2); !ok || ft.String() != "(i64) -> (i64, f32)" {
+		t.Errorf("FuncType(2) = %v, %v", ft, ok)
+	}
+	if _, ok := m.FuncType(3); ok {
+		t.Error("FuncType(3) succeeded for a module with 3 functions")
+	}
+	if m.FuncName(1) != "_start" || m.FuncName(2) != "pair" || m.FuncName(0) != "" {
+		t.Errorf("names = %v", m.Names.Functions)
+	}
+
+	var sections []string
+	for _, s := range m.Sections {
+		sections = append(sections, s.Name)
+	}
+	wantSections := []string{"type", "import", "function", "global", "export", "code", "data", "custom:name", "custom:producers"}
+	if !reflect.DeepEqual(sections, wantSections) {
+		t.Errorf("sections = %q, want %q", sections, wantSections)
+	}
+	if s := m.Sections[0]; s.Offset != 10 || s.Size != 18 {
+		t.Errorf("type section at %d size %d, want 10 size 18", s.Offset, s.Size)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	typeSec := section(SectionType, vector([]byte{0x60, 0, 0}))
+	tests := []struct {
+		name string
+		data []byte
+		want string
+	}{
+		{"empty", nil, "offset 0x0: missing \\0asm magic number"},
+		{"not wasm", []byte("\x7fELF\x02\x01\x01\x00"), "missing \\0asm magic number"},
+		{"version", []byte("\x00asm\x02\x00\x00\x00"), "offset 0x4: unsupported version 2"},
+		{"unknown section", module([]byte{14, 0}), "offset 0x8: unknown section id 14"},
+		{"out of order", module(section(SectionFunction, vector()), typeSec), "type section out of order or duplicated"},
+		{"duplicate", module(typeSec, typeSec), "type section out of order or duplicated"},
+		{"truncated section", module([]byte{SectionType, 10, 1}), "offset 0xa: vector length 10 exceeds remaining 1 bytes"},
+		{"trailing bytes", module(section(SectionType, vector([]byte{0x60, 0, 0}), []byte{0})), "type section has 1 trailing bytes"},
+		{"bad value type", module(section(SectionType, vector([]byte{0x60, 1, 0x40, 0}))), "offset 0xd: invalid value type 0x40"},
+		{"bad form", module(section(SectionType, vector([]byte{0x61, 0, 0}))), "expected function type 0x60, got 0x61"},
+		{"leb overflow", module(section(SectionFunction, []byte{0x80, 0x80, 0x80, 0x80, 0x10})), "integer too large for u32"},
+		{"missing code", module(typeSec, section(SectionFunction, vector([]byte{0}))), "function section declares 1 functions but code section has 0 bodies"},
+		{"missing end", module(typeSec, section(SectionFunction, vector([]byte{0})), section(SectionCode, vector(str("\x00\x01")))), "function body does not end with the end opcode"},
+		{"bad name", module(section(SectionCustom, str("\xff"))), "name is not valid UTF-8"},
+		{"data count", module(section(SectionDataCount, uleb(2))), "data count 2 does not match 0 data segments"},
+	}
+	for _, tt := range tests {
+		_, err := Parse(tt.data)
+		var perr *ParseError
+		if !errors.As(err, &perr) {
+			t.Errorf("%s: got %v, want a *ParseError", tt.name, err)
+			continue
+		}
+		if !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: got %q, want it to contain %q", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestLimits(t *testing.T) {
+	tests := []struct {
+		enc  []byte
+		want string
+	}{
+		{[]byte{0x00, 1}, "min 1, no max"},
+		{[]byte{0x01, 1, 2}, "min 1, max 2"},
+		{[]byte{0x03, 1, 2}, "min 1, max 2, shared"},
+		{cat([]byte{0x04}, uleb(1<<40)), "min 1099511627776, no max, 64-bit"},
+	}
+	for _, tt := range tests {
+		l, err := readLimits(&reader{data: tt.enc})
+		if err != nil {
+			t.Errorf("%x: %v", tt.enc, err)
+			continue
+		}
+		if l.String() != tt.want {
+			t.Errorf("%x: got %q, want %q", tt.enc, l, tt.want)
+		}
+	}
+}
+
+func TestSignedLEB(t *testing.T) {
+	tests := []struct {
+		enc  []byte
+		bits uint
+		want int64
+	}{
+		{[]byte{0x7f}, 32, -1},
+		{[]byte{0x80, 0x7f}, 32, -128},
+		{[]byte{0x3f}, 32, 63},
+		{[]byte{0xff, 0xff, 0xff, 0xff, 0x07}, 32, 1<<31 - 1},
+		{[]byte{0x80, 0x80, 0x80, 0x80, 0x78}, 32, -1 << 31},
+		{[]byte{0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x7f}, 64, -1 << 63},
+	}
+	for _, tt := range tests {
+		got, err := (&reader{data: tt.enc}).sleb(tt.bits)
+		if err != nil || got != tt.want {
+			t.Errorf("sleb(%x, %d) = %d, %v; want %d", tt.enc, tt.bits, got, err, tt.want)
+		}
+	}
+}
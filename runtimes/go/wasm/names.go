@@ -0,0 +1,105 @@
+package wasm
+
+// Names holds the contents of the "name" custom section.
+type Names struct {
+	Module    string
+	Functions map[uint32]string
+	Locals    map[uint32]map[uint32]string
+}
+
+// parseNames decodes the module, function and local name subsections.
+// Other subsections (labels, types, fields, ...) are skipped.
+func parseNames(r *reader) (Names, error) {
+	var n Names
+	for !r.eof() {
+		id, err := r.byte()
+		if err != nil {
+			return n, err
+		}
+		size, err := r.count()
+		if err != nil {
+			return n, err
+		}
+		body, _ := r.bytes(size)
+		sub := &reader{data: body, base: r.offset() - size}
+		switch id {
+		case 0:
+			n.Module, err = sub.name()
+		case 1:
+			n.Functions, err = readNameMap(sub)
+		case 2:
+			n.Locals = make(map[uint32]map[uint32]string)
+			err = vec(sub, func() error {
+				fn, err := sub.u32()
+				if err != nil {
+					return err
+				}
+				n.Locals[fn], err = readNameMap(sub)
+				return err
+			})
+		}
+		if err != nil {
+			return n, err
+		}
+	}
+	return n, nil
+}
+
+func readNameMap(r *reader) (map[uint32]string, error) {
+	names := make(map[uint32]string)
+	err := vec(r, func() error {
+		idx, err := r.u32()
+		if err != nil {
+			return err
+		}
+		names[idx], err = r.name()
+		return err
+	})
+	return names, err
+}
+
+// FuncName returns the name of function idx (imports first, then defined
+// functions) from the name section, or "" if it has none.
+func (m *Module) FuncName(idx uint32) string {
+	return m.Names.Functions[idx]
+}
+
+// ImportedFuncs returns the number of imported functions, which precede
+// defined functions in the function index space.
+func (m *Module) ImportedFuncs() int {
+	n := 0
+	for _, imp := range m.Imports {
+		if imp.Kind == KindFunc {
+			n++
+		}
+	}
+	return n
+}
+
+// FuncType returns the signature of function idx, or false if idx or its
+// type index is out of range.
+func (m *Module) FuncType(idx uint32) (FuncType, bool) {
+	var typeIdx uint32
+	imported := uint32(0)
+	found := false
+	for _, imp := range m.Imports {
+		if imp.Kind != KindFunc {
+			continue
+		}
+		if imported == idx {
+			typeIdx, found = imp.Type, true
+			break
+		}
+		imported++
+	}
+	if !found {
+		if idx < imported || int(idx-imported) >= len(m.Functions) {
+			return FuncType{}, false
+		}
+		typeIdx = m.Functions[idx-imported]
+	}
+	if int(typeIdx) >= len(m.Types) {
+		return FuncType{}, false
+	}
+	return m.Types[typeIdx], true
+}
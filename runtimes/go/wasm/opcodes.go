@@ -0,0 +1,166 @@
+package wasm
+
+// immediate describes the operands that follow an opcode.
+type immediate uint8
+
+const (
+	immNone         immediate = iota
+	immBlock                  // block type
+	immLabel                  // label depth
+	immBrTable                // vec(label) default
+	immFunc                   // function index
+	immCallIndirect           // type index, table index
+	immLocal                  // local index
+	immGlobal                 // global index
+	immTable                  // table index
+	immMemArg                 // alignment, offset
+	immMemory                 // memory index
+	immI32
+	immI64
+	immF32
+	immF64
+	immSelect     // vec(value type)
+	immRefType    // reference type
+	immData       // data segment index
+	immMemoryInit // data segment index, memory index
+	immMemoryCopy // destination and source memory index
+	immElem       // element segment index
+	immTableInit  // element segment index, table index
+	immTableCopy  // destination and source table index
+)
+
+type opcode struct {
+	name  string
+	imm   immediate
+	align uint32 // natural alignment (log2) of memory accesses
+}
+
+// opcodes holds the single-byte instructions: the MVP set, sign extension,
+// reference types and tail calls. prefixedOpcodes holds those behind the
+// 0xfc prefix: saturating truncation, bulk memory and table operations.
+var (
+	opcodes         [256]*opcode
+	prefixedOpcodes = map[uint32]*opcode{}
+)
+
+func init() {
+	def := func(code byte, name string, imm immediate) {
+		opcodes[code] = &opcode{name: name, imm: imm}
+	}
+	// run defines consecutive opcodes without immediates.
+	run := func(first byte, names ...string) {
+		for i, name := range names {
+			def(first+byte(i), name, immNone)
+		}
+	}
+	mem := func(code byte, name string, align uint32) {
+		opcodes[code] = &opcode{name: name, imm: immMemArg, align: align}
+	}
+
+	run(0x00, "unreachable", "nop")
+	def(0x02, "block", immBlock)
+	def(0x03, "loop", immBlock)
+	def(0x04, "if", immBlock)
+	def(0x05, "else", immNone)
+	def(0x0b, "end", immNone)
+	def(0x0c, "br", immLabel)
+	def(0x0d, "br_if", immLabel)
+	def(0x0e, "br_table", immBrTable)
+	def(0x0f, "return", immNone)
+	def(0x10, "call", immFunc)
+	def(0x11, "call_indirect", immCallIndirect)
+	def(0x12, "return_call", immFunc)
+	def(0x13, "return_call_indirect", immCallIndirect)
+
+	run(0x1a, "drop", "select")
+	def(0x1c, "select", immSelect)
+	def(0x20, "local.get", immLocal)
+	def(0x21, "local.set", immLocal)
+	def(0x22, "local.tee", immLocal)
+	def(0x23, "global.get", immGlobal)
+	def(0x24, "global.set", immGlobal)
+	def(0x25, "table.get", immTable)
+	def(0x26, "table.set", immTable)
+
+	mem(0x28, "i32.load", 2)
+	mem(0x29, "i64.load", 3)
+	mem(0x2a, "f32.load", 2)
+	mem(0x2b, "f64.load", 3)
+	mem(0x2c, "i32.load8_s", 0)
+	mem(0x2d, "i32.load8_u", 0)
+	mem(0x2e, "i32.load16_s", 1)
+	mem(0x2f, "i32.load16_u", 1)
+	mem(0x30, "i64.load8_s", 0)
+	mem(0x31, "i64.load8_u", 0)
+	mem(0x32, "i64.load16_s", 1)
+	mem(0x33, "i64.load16_u", 1)
+	mem(0x34, "i64.load32_s", 2)
+	mem(0x35, "i64.load32_u", 2)
+	mem(0x36, "i32.store", 2)
+	mem(0x37, "i64.store", 3)
+	mem(0x38, "f32.store", 2)
+	mem(0x39, "f64.store", 3)
+	mem(0x3a, "i32.store8", 0)
+	mem(0x3b, "i32.store16", 1)
+	mem(0x3c, "i64.store8", 0)
+	mem(0x3d, "i64.store16", 1)
+	mem(0x3e, "i64.store32", 2)
+	def(0x3f, "memory.size", immMemory)
+	def(0x40, "memory.grow", immMemory)
+
+	def(0x41, "i32.const", immI32)
+	def(0x42, "i64.const", immI64)
+	def(0x43, "f32.const", immF32)
+	def(0x44, "f64.const", immF64)
+
+	run(0x45, "i32.eqz", "i32.eq", "i32.ne", "i32.lt_s", "i32.lt_u", "i32.gt_s",
+		"i32.gt_u", "i32.le_s", "i32.le_u", "i32.ge_s", "i32.ge_u")
+	run(0x50, "i64.eqz", "i64.eq", "i64.ne", "i64.lt_s", "i64.lt_u", "i64.gt_s",
+		"i64.gt_u", "i64.le_s", "i64.le_u", "i64.ge_s", "i64.ge_u")
+	run(0x5b, "f32.eq", "f32.ne", "f32.lt", "f32.gt", "f32.le", "f32.ge")
+	run(0x61, "f64.eq", "f64.ne", "f64.lt", "f64.gt", "f64.le", "f64.ge")
+
+	run(0x67, "i32.clz", "i32.ctz", "i32.popcnt", "i32.add", "i32.sub", "i32.mul",
+		"i32.div_s", "i32.div_u", "i32.rem_s", "i32.rem_u", "i32.and", "i32.or",
+		"i32.xor", "i32.shl", "i32.shr_s", "i32.shr_u", "i32.rotl", "i32.rotr")
+	run(0x79, "i64.clz", "i64.ctz", "i64.popcnt", "i64.add", "i64.sub", "i64.mul",
+		"i64.div_s", "i64.div_u", "i64.rem_s", "i64.rem_u", "i64.and", "i64.or",
+		"i64.xor", "i64.shl", "i64.shr_s", "i64.shr_u", "i64.rotl", "i64.rotr")
+	run(0x8b, "f32.abs", "f32.neg", "f32.ceil", "f32.floor", "f32.trunc", "f32.nearest",
+		"f32.sqrt", "f32.add", "f32.sub", "f32.mul", "f32.div", "f32.min", "f32.max",
+		"f32.copysign")
+	run(0x99, "f64.abs", "f64.neg", "f64.ceil", "f64.floor", "f64.trunc", "f64.nearest",
+		"f64.sqrt", "f64.add", "f64.sub", "f64.mul", "f64.div", "f64.min", "f64.max",
+		"f64.copysign")
+
+	run(0xa7, "i32.wrap_i64", "i32.trunc_f32_s", "i32.trunc_f32_u", "i32.trunc_f64_s",
+		"i32.trunc_f64_u", "i64.extend_i32_s", "i64.extend_i32_u", "i64.trunc_f32_s",
+		"i64.trunc_f32_u", "i64.trunc_f64_s", "i64.trunc_f64_u", "f32.convert_i32_s",
+		"f32.convert_i32_u", "f32.convert_i64_s", "f32.convert_i64_u", "f32.demote_f64",
+		"f64.convert_i32_s", "f64.convert_i32_u", "f64.convert_i64_s", "f64.convert_i64_u",
+		"f64.promote_f32", "i32.reinterpret_f32", "i64.reinterpret_f64",
+		"f32.reinterpret_i32", "f64.reinterpret_i64")
+	run(0xc0, "i32.extend8_s", "i32.extend16_s", "i64.extend8_s", "i64.extend16_s",
+		"i64.extend32_s")
+
+	def(0xd0, "ref.null", immRefType)
+	def(0xd1, "ref.is_null", immNone)
+	def(0xd2, "ref.func", immFunc)
+
+	for i, name := range []string{
+		"i32.trunc_sat_f32_s", "i32.trunc_sat_f32_u", "i32.trunc_sat_f64_s", "i32.trunc_sat_f64_u",
+		"i64.trunc_sat_f32_s", "i64.trunc_sat_f32_u", "i64.trunc_sat_f64_s", "i64.trunc_sat_f64_u",
+	} {
+		prefixedOpcodes[uint32(i)] = &opcode{name: name}
+	}
+	prefixedOpcodes[8] = &opcode{name: "memory.init", imm: immMemoryInit}
+	prefixedOpcodes[9] = &opcode{name: "data.drop", imm: immData}
+	prefixedOpcodes[10] = &opcode{name: "memory.copy", imm: immMemoryCopy}
+	prefixedOpcodes[11] = &opcode{name: "memory.fill", imm: immMemory}
+	prefixedOpcodes[12] = &opcode{name: "table.init", imm: immTableInit}
+	prefixedOpcodes[13] = &opcode{name: "elem.drop", imm: immElem}
+	prefixedOpcodes[14] = &opcode{name: "table.copy", imm: immTableCopy}
+	prefixedOpcodes[15] = &opcode{name: "table.grow", imm: immTable}
+	prefixedOpcodes[16] = &opcode{name: "table.size", imm: immTable}
+	prefixedOpcodes[17] = &opcode{name: "table.fill", imm: immTable}
+}
@@ -0,0 +1,151 @@
+package wasm
+
+import (
+	"fmt"
+	"math"
+	"unicode/utf8"
+)
+
+// ParseError reports malformed input together with the file offset at
+// which it was detected.
+type ParseError struct {
+	Offset int
+	Msg    string
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("offset 0x%x: %s", e.Offset, e.Msg)
+}
+
+// reader decodes the primitive encodings of the binary format. Offsets in
+// errors are relative to the start of the file, via base.
+type reader struct {
+	data []byte
+	pos  int
+	base int
+}
+
+func (r *reader) errorf(format string, args ...any) error {
+	return &ParseError{Offset: r.base + r.pos, Msg: fmt.Sprintf(format, args...)}
+}
+
+func (r *reader) eof() bool { return r.pos >= len(r.data) }
+
+func (r *reader) offset() int { return r.base + r.pos }
+
+func (r *reader) byte() (byte, error) {
+	if r.eof() {
+		return 0, r.errorf("unexpected end of input")
+	}
+	b := r.data[r.pos]
+	r.pos++
+	return b, nil
+}
+
+func (r *reader) bytes(n int) ([]byte, error) {
+	if n < 0 || n > len(r.data)-r.pos {
+		return nil, r.errorf("length %d exceeds remaining %d bytes", n, len(r.data)-r.pos)
+	}
+	b := r.data[r.pos : r.pos+n]
+	r.pos += n
+	return b, nil
+}
+
+// uleb decodes an unsigned LEB128 value of at most bits bits.
+func (r *reader) uleb(bits uint) (uint64, error) {
+	start := r.pos
+	var result uint64
+	for shift := uint(0); ; shift += 7 {
+		b, err := r.byte()
+		if err != nil {
+			return 0, err
+		}
+		if shift >= bits || (shift+7 > bits && b&0x7f>>(bits-shift) != 0) {
+			r.pos = start
+			return 0, r.errorf("integer too large for u%d", bits)
+		}
+		result |= uint64(b&0x7f) << shift
+		if b&0x80 == 0 {
+			return result, nil
+		}
+	}
+}
+
+// sleb decodes a signed LEB128 value of at most bits bits.
+func (r *reader) sleb(bits uint) (int64, error) {
+	start := r.pos
+	var result int64
+	var shift uint
+	for {
+		b, err := r.byte()
+		if err != nil {
+			return 0, err
+		}
+		if shift >= bits {
+			r.pos = start
+			return 0, r.errorf("integer too large for s%d", bits)
+		}
+		result |= int64(b&0x7f) << shift
+		shift += 7
+		if b&0x80 == 0 {
+			if shift < 64 && b&0x40 != 0 {
+				result |= -1 << shift
+			}
+			return result, nil
+		}
+	}
+}
+
+func (r *reader) u32() (uint32, error) {
+	v, err := r.uleb(32)
+	return uint32(v), err
+}
+
+// count reads a vector length, rejecting lengths that could not possibly
+// fit in the remaining input so corrupt files fail fast.
+func (r *reader) count() (int, error) {
+	n, err := r.u32()
+	if err != nil {
+		return 0, err
+	}
+	if int(n) > len(r.data)-r.pos {
+		return 0, r.errorf("vector length %d exceeds remaining %d bytes", n, len(r.data)-r.pos)
+	}
+	return int(n), nil
+}
+
+func (r *reader) name() (string, error) {
+	n, err := r.count()
+	if err != nil {
+		return "", err
+	}
+	b, err := r.bytes(n)
+	if err != nil {
+		return "", err
+	}
+	if !utf8.Valid(b) {
+		return "", r.errorf("name is not valid UTF-8")
+	}
+	return string(b), nil
+}
+
+func (r *reader) f32() (float32, error) {
+	b, err := r.bytes(4)
+	if err != nil {
+		return 0, err
+	}
+	bits := uint32(b[0]) | uint32(b[1])<<8 | uint32(b[2])<<16 | uint32(b[3])<<24
+	return math.Float32frombits(bits), nil
+}
+
+func (r *reader) f64() (float64, error) {
+	b, err := r.bytes(8)
+	if err != nil {
+		return 0, err
+	}
+	var bits uint64
+	for i := 7; i >= 0; i-- {
+		bits = bits<<8 | uint64(b[i])
+	}
+	return math.Float64frombits(bits), nil
+}
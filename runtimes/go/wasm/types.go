@@ -0,0 +1,190 @@
+package wasm
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ValType is a value type as encoded in the binary format.
+type ValType byte
+
+const (
+	I32       ValType = 0x7f
+	I64       ValType = 0x7e
+	F32       ValType = 0x7d
+	F64       ValType = 0x7c
+	V128      ValType = 0x7b
+	FuncRef   ValType = 0x70
+	ExternRef ValType = 0x6f
+)
+
+func (t ValType) String() string {
+	switch t {
+	case I32:
+		return "i32"
+	case I64:
+		return "i64"
+	case F32:
+		return "f32"
+	case F64:
+		return "f64"
+	case V128:
+		return "v128"
+	case FuncRef:
+		return "funcref"
+	case ExternRef:
+		return "externref"
+	}
+	return fmt.Sprintf("type(0x%02x)", byte(t))
+}
+
+func (t ValType) valid() bool {
+	switch t {
+	case I32, I64, F32, F64, V128, FuncRef, ExternRef:
+		return true
+	}
+	return false
+}
+
+// FuncType is a function signature. Results may hold several values
+// (multi-value).
+type FuncType struct {
+	Params  []ValType
+	Results []ValType
+}
+
+// String formats the signature as "(i32, i32) -> i32".
+func (f FuncType) String() string {
+	return "(" + joinTypes(f.Params) + ") -> " + resultString(f.Results)
+}
+
+func resultString(results []ValType) string {
+	if len(results) == 1 {
+		return results[0].String()
+	}
+	return "(" + joinTypes(results) + ")"
+}
+
+func joinTypes(types []ValType) string {
+	s := make([]string, len(types))
+	for i, t := range types {
+		s[i] = t.String()
+	}
+	return strings.Join(s, ", ")
+}
+
+// ExternKind is the kind of an import or export.
+type ExternKind byte
+
+const (
+	KindFunc   ExternKind = 0
+	KindTable  ExternKind = 1
+	KindMemory ExternKind = 2
+	KindGlobal ExternKind = 3
+	KindTag    ExternKind = 4
+)
+
+func (k ExternKind) String() string {
+	switch k {
+	case KindFunc:
+		return "func"
+	case KindTable:
+		return "table"
+	case KindMemory:
+		return "memory"
+	case KindGlobal:
+		return "global"
+	case KindTag:
+		return "tag"
+	}
+	return fmt.Sprintf("kind(%d)", byte(k))
+}
+
+// Limits bound the size of a table or memory. Max is nil when unbounded.
+type Limits struct {
+	Min    uint64
+	Max    *uint64
+	Shared bool
+	Is64   bool
+}
+
+func (l Limits) String() string {
+	s := fmt.Sprintf("min %d", l.Min)
+	if l.Max != nil {
+		s += fmt.Sprintf(", max %d", *l.Max)
+	} else {
+		s += ", no max"
+	}
+	if l.Shared {
+		s += ", shared"
+	}
+	if l.Is64 {
+		s += ", 64-bit"
+	}
+	return s
+}
+
+// Table is a table type.
+type Table struct {
+	Elem   ValType
+	Limits Limits
+}
+
+// GlobalType is the type of a global variable.
+type GlobalType struct {
+	Type    ValType
+	Mutable bool
+}
+
+func (g GlobalType) String() string {
+	if g.Mutable {
+		return "mut " + g.Type.String()
+	}
+	return g.Type.String()
+}
+
+// Import is an entry of the import section. Exactly one of the descriptor
+// fields is meaningful, as selected by Kind.
+type Import struct {
+	Module string
+	Name   string
+	Kind   ExternKind
+	Type   uint32 // KindFunc, KindTag: type index
+	Table  Table
+	Memory Limits
+	Global GlobalType
+}
+
+// Export is an entry of the export section.
+type Export struct {
+	Name  string
+	Kind  ExternKind
+	Index uint32
+}
+
+// Global is a defined global; Init is its raw constant initializer
+// expression, without the trailing end opcode.
+type Global struct {
+	Type GlobalType
+	Init []byte
+}
+
+// Code is the body of a defined function.
+type Code struct {
+	Locals []Local
+	Body   []byte // instructions, including the final end opcode
+	Offset int    // file offset of Body
+}
+
+// Local is a run of locals of one type in a function body.
+type Local struct {
+	Count uint32
+	Type  ValType
+}
+
+// CustomSection is a custom section; the name section is also decoded
+// into Module.Names.
+type CustomSection struct {
+	Name string
+	Data []byte
+}
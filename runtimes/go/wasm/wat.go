@@ -0,0 +1,568 @@
+package wasm
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// WriteWAT writes m in the WebAssembly text format. Function bodies are
+// printed as flat instruction sequences; element and data segment contents
+// are left out. If funcs is non-empty only those functions are written,
+// without the surrounding module.
+func WriteWAT(w io.Writer, m *Module, funcs ...uint32) error {
+	p := newPrinter(m, w)
+	if len(funcs) > 0 {
+		p.base = -1
+		for _, idx := range funcs {
+			if err := p.function(idx); err != nil {
+				return err
+			}
+		}
+		return p.w.Flush()
+	}
+	if err := p.module(); err != nil {
+		return err
+	}
+	return p.w.Flush()
+}
+
+// FuncIndex resolves a function by its name in the name section, its
+// export name, or its decimal index.
+func (m *Module) FuncIndex(name string) (uint32, bool) {
+	total := uint32(m.ImportedFuncs() + len(m.Functions))
+	var named []uint32
+	for idx, n := range m.Names.Functions {
+		if n == name || "$"+n == name {
+			named = append(named, idx)
+		}
+	}
+	if len(named) > 0 {
+		// Names are not required to be unique; prefer the lowest index.
+		sort.Slice(named, func(i, j int) bool { return named[i] < named[j] })
+		return named[0], named[0] < total
+	}
+	for _, e := range m.Exports {
+		if e.Kind == KindFunc && e.Name == name {
+			return e.Index, e.Index < total
+		}
+	}
+	if n, err := strconv.ParseUint(name, 10, 32); err == nil && uint32(n) < total {
+		return uint32(n), true
+	}
+	return 0, false
+}
+
+type printer struct {
+	m        *Module
+	w        *bufio.Writer
+	ids      map[uint32]string // function index -> "$id"
+	imported uint32
+	base     int // indentation of top-level fields
+}
+
+func newPrinter(m *Module, w io.Writer) *printer {
+	p := &printer{m: m, w: bufio.NewWriter(w), ids: make(map[uint32]string), imported: uint32(m.ImportedFuncs())}
+
+	indices := make([]uint32, 0, len(m.Names.Functions))
+	for idx := range m.Names.Functions {
+		indices = append(indices, idx)
+	}
+	sort.Slice(indices, func(i, j int) bool { return indices[i] < indices[j] })
+	used := make(map[string]bool)
+	for _, idx := range indices {
+		id := identifier(m.Names.Functions[idx])
+		if used[id] {
+			id += "." + strconv.FormatUint(uint64(idx), 10)
+		}
+		used[id] = true
+		p.ids[idx] = id
+	}
+	return p
+}
+
+// identifier turns a name into a text-format identifier, replacing
+// characters that may not appear in one (as in "(*os.File).Write").
+func identifier(name string) string {
+	var b strings.Builder
+	b.WriteByte('$')
+	for _, c := range name {
+		if c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' ||
+			strings.ContainsRune("!#$%&'*+-./:<=>?@\\^_`|~", c) {
+			b.WriteRune(c)
+		} else {
+			b.WriteByte('_')
+		}
+	}
+	if b.Len() == 1 {
+		b.WriteByte('_')
+	}
+	return b.String()
+}
+
+func (p *printer) line(depth int, format string, args ...any) {
+	p.w.WriteString(strings.Repeat("  ", p.base+depth))
+	fmt.Fprintf(p.w, format, args...)
+	p.w.WriteByte('\n')
+}
+
+func (p *printer) funcRef(idx uint32) string {
+	if id, ok := p.ids[idx]; ok {
+		return id
+	}
+	return strconv.FormatUint(uint64(idx), 10)
+}
+
+func (p *printer) module() error {
+	m := p.m
+	if m.Names.Module != "" {
+		p.line(0, "(module %s", identifier(m.Names.Module))
+	} else {
+		p.line(0, "(module")
+	}
+	for i, t := range m.Types {
+		p.line(1, "(type (;%d;) (func%s))", i, signature(t))
+	}
+
+	var funcs, tables, memories, globals, tags uint32
+	for _, imp := range m.Imports {
+		var desc string
+		switch imp.Kind {
+		case KindFunc:
+			desc = fmt.Sprintf("(func %s(;%d;) (type %d))", p.idPrefix(funcs), funcs, imp.Type)
+			funcs++
+		case KindTable:
+			desc = fmt.Sprintf("(table (;%d;) %s %s)", tables, limits(imp.Table.Limits), imp.Table.Elem)
+			tables++
+		case KindMemory:
+			desc = fmt.Sprintf("(memory (;%d;) %s)", memories, limits(imp.Memory))
+			memories++
+		case KindGlobal:
+			desc = fmt.Sprintf("(global (;%d;) %s)", globals, globalType(imp.Global))
+			globals++
+		case KindTag:
+			desc = fmt.Sprintf("(tag (;%d;) (type %d))", tags, imp.Type)
+			tags++
+		}
+		p.line(1, "(import %s %s %s)", strconv.Quote(imp.Module), strconv.Quote(imp.Name), desc)
+	}
+
+	for i := range m.Code {
+		if err := p.function(p.imported + uint32(i)); err != nil {
+			return err
+		}
+	}
+	for i, t := range m.Tables {
+		p.line(1, "(table (;%d;) %s %s)", tables+uint32(i), limits(t.Limits), t.Elem)
+	}
+	for i, l := range m.Memories {
+		p.line(1, "(memory (;%d;) %s)", memories+uint32(i), limits(l))
+	}
+	for i, t := range m.Tags {
+		p.line(1, "(tag (;%d;) (type %d))", tags+uint32(i), t)
+	}
+	for i, g := range m.Globals {
+		init, err := p.constExpr(g.Init)
+		if err != nil {
+			return err
+		}
+		p.line(1, "(global (;%d;) %s %s)", globals+uint32(i), globalType(g.Type), init)
+	}
+	for _, e := range m.Exports {
+		ref := strconv.FormatUint(uint64(e.Index), 10)
+		if e.Kind == KindFunc {
+			ref = p.funcRef(e.Index)
+		}
+		p.line(1, "(export %s (%s %s))", strconv.Quote(e.Name), e.Kind, ref)
+	}
+	if m.Start != nil {
+		p.line(1, "(start %s)", p.funcRef(*m.Start))
+	}
+	if m.Elements > 0 || m.Data > 0 {
+		p.line(1, ";; %d element segment(s) and %d data segment(s) not shown", m.Elements, m.Data)
+	}
+	p.line(0, ")")
+	return nil
+}
+
+// idPrefix returns a function's identifier followed by a space, or "".
+func (p *printer) idPrefix(idx uint32) string {
+	if id, ok := p.ids[idx]; ok {
+		return id + " "
+	}
+	return ""
+}
+
+func (p *printer) function(idx uint32) error {
+	if idx < p.imported || int(idx-p.imported) >= len(p.m.Code) {
+		return fmt.Errorf("function %d is not defined in this module", idx)
+	}
+	code := p.m.Code[idx-p.imported]
+	typeIdx := p.m.Functions[idx-p.imported]
+	localNames := p.m.Names.Locals[idx]
+
+	header := fmt.Sprintf("(func %s(;%d;) (type %d)", p.idPrefix(idx), idx, typeIdx)
+	nparams := uint32(0)
+	if int(typeIdx) < len(p.m.Types) {
+		t := p.m.Types[typeIdx]
+		nparams = uint32(len(t.Params))
+		header += params("param", t.Params, 0, localNames) + results(t.Results)
+	}
+	p.line(1, "%s", header)
+
+	next := nparams
+	for _, l := range code.Locals {
+		if l.Count == 0 {
+			continue
+		}
+		types := make([]ValType, l.Count)
+		for i := range types {
+			types[i] = l.Type
+		}
+		p.line(2, "%s", strings.TrimSpace(params("local", types, next, localNames)))
+		next += l.Count
+	}
+
+	r := &reader{data: code.Body, base: code.Offset}
+	depth := 2
+	for {
+		op, text, err := p.instr(r, localNames)
+		if err != nil {
+			return err
+		}
+		switch op.name {
+		case "end":
+			if r.eof() {
+				if depth != 2 {
+					return r.errorf("function %d ends inside a block", idx)
+				}
+				p.line(1, ")")
+				return nil
+			}
+			depth--
+			if depth < 2 {
+				return r.errorf("unbalanced end in function %d", idx)
+			}
+		case "else":
+			if depth <= 2 {
+				return r.errorf("else outside of if in function %d", idx)
+			}
+			p.line(depth-1, "%s", text)
+			continue
+		}
+		if r.eof() {
+			return r.errorf("function %d is missing its final end", idx)
+		}
+		p.line(depth, "%s", text)
+		switch op.name {
+		case "block", "loop", "if":
+			depth++
+		}
+	}
+}
+
+// constExpr prints an initializer expression in folded form.
+func (p *printer) constExpr(expr []byte) (string, error) {
+	r := &reader{data: expr}
+	var parts []string
+	for !r.eof() {
+		_, text, err := p.instr(r, nil)
+		if err != nil {
+			return "", err
+		}
+		parts = append(parts, "("+text+")")
+	}
+	return strings.Join(parts, " "), nil
+}
+
+// instr decodes the instruction at r and formats it with its immediates.
+func (p *printer) instr(r *reader, localNames map[uint32]string) (*opcode, string, error) {
+	code, err := r.byte()
+	if err != nil {
+		return nil, "", err
+	}
+	op := opcodes[code]
+	if code == 0xfc {
+		sub, err := r.u32()
+		if err != nil {
+			return nil, "", err
+		}
+		if op = prefixedOpcodes[sub]; op == nil {
+			return nil, "", r.errorf("unknown instruction 0xfc %d", sub)
+		}
+	} else if op == nil {
+		r.pos--
+		return nil, "", r.errorf("unknown opcode 0x%02x", code)
+	}
+
+	imm, err := p.immediates(r, op, localNames)
+	if err != nil {
+		return nil, "", err
+	}
+	return op, op.name + imm, nil
+}
+
+func (p *printer) immediates(r *reader, op *opcode, localNames map[uint32]string) (string, error) {
+	// index reads a u32 operand and formats it with a leading space.
+	index := func() (string, error) {
+		v, err := r.u32()
+		return " " + strconv.FormatUint(uint64(v), 10), err
+	}
+	// indexPair reads two u32 operands, printing them only when either is
+	// non-zero, as both default to 0 in the text format.
+	indexPair := func() (string, error) {
+		a, err := r.u32()
+		if err != nil {
+			return "", err
+		}
+		b, err := r.u32()
+		if a == 0 && b == 0 {
+			return "", err
+		}
+		return fmt.Sprintf(" %d %d", a, b), err
+	}
+
+	switch op.imm {
+	case immNone:
+		return "", nil
+	case immBlock:
+		return blockType(r)
+	case immLabel, immGlobal, immTable, immData, immElem:
+		return index()
+	case immBrTable:
+		var b strings.Builder
+		err := vec(r, func() error {
+			s, err := index()
+			b.WriteString(s)
+			return err
+		})
+		if err != nil {
+			return "", err
+		}
+		s, err := index()
+		return b.String() + s, err
+	case immFunc:
+		v, err := r.u32()
+		return " " + p.funcRef(v), err
+	case immCallIndirect:
+		typeIdx, err := r.u32()
+		if err != nil {
+			return "", err
+		}
+		table, err := r.u32()
+		if table != 0 {
+			return fmt.Sprintf(" %d (type %d)", table, typeIdx), err
+		}
+		return fmt.Sprintf(" (type %d)", typeIdx), err
+	case immLocal:
+		v, err := r.u32()
+		if name, ok := localNames[v]; ok {
+			return " " + identifier(name), err
+		}
+		return " " + strconv.FormatUint(uint64(v), 10), err
+	case immMemArg:
+		return memArg(r, op.align)
+	case immMemory:
+		v, err := r.u32()
+		if v != 0 {
+			return " " + strconv.FormatUint(uint64(v), 10), err
+		}
+		return "", err
+	case immI32:
+		v, err := r.sleb(32)
+		return " " + strconv.FormatInt(int64(int32(v)), 10), err
+	case immI64:
+		v, err := r.sleb(64)
+		return " " + strconv.FormatInt(v, 10), err
+	case immF32:
+		v, err := r.f32()
+		return " " + formatF32(v), err
+	case immF64:
+		v, err := r.f64()
+		return " " + formatF64(v), err
+	case immSelect:
+		types, err := readValTypes(r)
+		return results(types), err
+	case immRefType:
+		t, err := readValType(r)
+		return " " + strings.TrimSuffix(t.String(), "ref"), err
+	case immMemoryInit:
+		data, err := r.u32()
+		if err != nil {
+			return "", err
+		}
+		mem, err := r.u32()
+		if mem != 0 {
+			return fmt.Sprintf(" %d %d", mem, data), err
+		}
+		return " " + strconv.FormatUint(uint64(data), 10), err
+	case immMemoryCopy, immTableCopy:
+		return indexPair()
+	case immTableInit:
+		elem, err := r.u32()
+		if err != nil {
+			return "", err
+		}
+		table, err := r.u32()
+		if table != 0 {
+			return fmt.Sprintf(" %d %d", table, elem), err
+		}
+		return " " + strconv.FormatUint(uint64(elem), 10), err
+	}
+	return "", r.errorf("unhandled immediate for %s", op.name)
+}
+
+// blockType reads the type of a block, loop or if: empty, a single result,
+// or (multi-value) an index into the type section.
+func blockType(r *reader) (string, error) {
+	b, err := r.byte()
+	if err != nil {
+		return "", err
+	}
+	if b == 0x40 {
+		return "", nil
+	}
+	if t := ValType(b); t.valid() {
+		return " (result " + t.String() + ")", nil
+	}
+	r.pos--
+	idx, err := r.sleb(33)
+	if err != nil {
+		return "", err
+	}
+	if idx < 0 {
+		return "", r.errorf("invalid block type %d", idx)
+	}
+	return fmt.Sprintf(" (type %d)", idx), nil
+}
+
+func memArg(r *reader, natural uint32) (string, error) {
+	align, err := r.u32()
+	if err != nil {
+		return "", err
+	}
+	var s string
+	if align&0x40 != 0 {
+		// Multi-memory: the memory index follows the alignment.
+		mem, err := r.u32()
+		if err != nil {
+			return "", err
+		}
+		align &^= 0x40
+		s = " " + strconv.FormatUint(uint64(mem), 10)
+	}
+	offset, err := r.uleb(64)
+	if err != nil {
+		return "", err
+	}
+	if offset != 0 {
+		s += " offset=" + strconv.FormatUint(offset, 10)
+	}
+	if align != natural {
+		if align >= 32 {
+			return "", r.errorf("invalid alignment 2**%d", align)
+		}
+		s += " align=" + strconv.FormatUint(1<<align, 10)
+	}
+	return s, nil
+}
+
+func formatF32(v float32) string {
+	bits := math.Float32bits(v)
+	sign := ""
+	if bits>>31 != 0 {
+		sign = "-"
+	}
+	switch {
+	case math.IsInf(float64(v), 0):
+		return sign + "inf"
+	case v != v:
+		if payload := bits & 0x7fffff; payload != 0x400000 {
+			return fmt.Sprintf("%snan:0x%x", sign, payload)
+		}
+		return sign + "nan"
+	}
+	return strconv.FormatFloat(float64(v), 'g', -1, 32)
+}
+
+func formatF64(v float64) string {
+	bits := math.Float64bits(v)
+	sign := ""
+	if bits>>63 != 0 {
+		sign = "-"
+	}
+	switch {
+	case math.IsInf(v, 0):
+		return sign + "inf"
+	case v != v:
+		if payload := bits & (1<<52 - 1); payload != 1<<51 {
+			return fmt.Sprintf("%snan:0x%x", sign, payload)
+		}
+		return sign + "nan"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+func signature(t FuncType) string {
+	return params("param", t.Params, 0, nil) + results(t.Results)
+}
+
+// params formats params or locals starting at index first. Named entries
+// are written one per clause so they can carry their identifier.
+func params(keyword string, types []ValType, first uint32, names map[uint32]string) string {
+	if len(types) == 0 {
+		return ""
+	}
+	if len(names) == 0 {
+		return " (" + keyword + " " + typeList(types) + ")"
+	}
+	var b strings.Builder
+	for i, t := range types {
+		b.WriteString(" (" + keyword + " ")
+		if name, ok := names[first+uint32(i)]; ok {
+			b.WriteString(identifier(name) + " ")
+		}
+		b.WriteString(t.String() + ")")
+	}
+	return b.String()
+}
+
+func results(types []ValType) string {
+	if len(types) == 0 {
+		return ""
+	}
+	return " (result " + typeList(types) + ")"
+}
+
+func typeList(types []ValType) string {
+	s := make([]string, len(types))
+	for i, t := range types {
+		s[i] = t.String()
+	}
+	return strings.Join(s, " ")
+}
+
+func limits(l Limits) string {
+	s := strconv.FormatUint(l.Min, 10)
+	if l.Is64 {
+		s = "i64 " + s
+	}
+	if l.Max != nil {
+		s += " " + strconv.FormatUint(*l.Max, 10)
+	}
+	if l.Shared {
+		s += " shared"
+	}
+	return s
+}
+
+func globalType(g GlobalType) string {
+	if g.Mutable {
+		return "(mut " + g.Type.String() + ")"
+	}
+	return g.Type.String()
+}
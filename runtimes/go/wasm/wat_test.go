@@ -0,0 +1,163 @@
+package wasm
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWriteWAT(t *testing.T) {
+	m, err := Parse(testModule())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var b strings.Builder
+	if err := WriteWAT(&b, m); err != nil {
+		t.Fatal(err)
+	}
+	want := `(module
+  (type (;0;) (func (param i32 i32 i32 i32) (result i32)))
+  (type (;1;) (func))
+  (type (;2;) (func (param i64) (result i64 f32)))
+  (import "wasi_snapshot_preview1" "fd_write" (func (;0;) (type 0)))
+  (import "env" "memory" (memory (;0;) 2 16))
+  (func $_start (;1;) (type 1)
+  )
+  (func $pair (;2;) (type 2) (param i64) (result i64 f32)
+    (local i32)
+    local.get 0
+    f32.const 1
+  )
+  (global (;0;) (mut i32) (i32.const 1024))
+  (export "_start" (func $_start))
+  (export "memory" (memory 0))
+  ;; 0 element segment(s) and 1 data segment(s) not shown
+)
+`
+	if got := b.String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+// instructionModule has one function of type (i32) -> i32 whose body is
+// body, with local 0 named "n".
+func instructionModule(body []byte) []byte {
+	return module(
+		section(SectionType, vector([]byte{0x60, 1, 0x7f, 1, 0x7f}, []byte{0x60, 1, 0x7f, 2, 0x7f, 0x7f})),
+		section(SectionFunction, vector([]byte{0})),
+		section(SectionMemory, vector([]byte{0x00, 1})),
+		section(SectionCode, vector(cat(uleb(uint64(len(body)+1)), []byte{0}, body))),
+		section(SectionCustom, str("name"),
+			section(1, vector(cat(uleb(0), str("(*T).f")))),
+			section(2, vector(cat(uleb(0), vector(cat(uleb(0), str("n")))))),
+		),
+	)
+}
+
+func TestWriteWATInstructions(t *testing.T) {
+	body := cat(
+		[]byte{0x02, 0x40},             // block
+		[]byte{0x03, 0x7f},             // loop (result i32)
+		[]byte{0x20, 0x00, 0x0d, 0x01}, // local.get $n, br_if 1
+		[]byte{0x41}, uleb(0x7f),       // i32.const -1 (0x7f is -1 as sleb)
+		[]byte{0x0b},                   // end
+		[]byte{0x0e, 2, 0, 1, 0},       // br_table 0 1 0
+		[]byte{0x0b},                   // end
+		[]byte{0x20, 0x00, 0x04, 0x01}, // local.get $n, if (type 1)
+		[]byte{0x41, 1, 0x41, 2},
+		[]byte{0x05},             // else
+		[]byte{0x41, 3, 0x41, 4}, // i32.const 3, i32.const 4
+		[]byte{0x0b},
+		[]byte{0x6a},             // i32.add
+		[]byte{0x28, 0x00, 0x08}, // i32.load offset=8 align=1
+		[]byte{0x3a, 0x00, 0x00}, // i32.store8
+		[]byte{0xc0},             // i32.extend8_s
+		[]byte{0x41, 0, 0x41, 0, 0x41, 0, 0xfc, 0x0a, 0, 0}, // memory.copy
+		[]byte{0x41, 0, 0x41, 0, 0x41, 0, 0xfc, 0x0b, 0},    // memory.fill
+		[]byte{0x42, 0x80, 0x7f},                            // i64.const -128
+		[]byte{0x44, 0, 0, 0, 0, 0, 0, 0xf0, 0x7f},          // f64.const inf
+		[]byte{0x43, 0, 0, 0xc0, 0x7f},                      // f32.const nan
+		[]byte{0x1a, 0x1a, 0x1a, 0x10, 0x00},                // drop x3, call 0
+		[]byte{0x0b},
+	)
+	m, err := Parse(instructionModule(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	idx, ok := m.FuncIndex("(*T).f")
+	if !ok || idx != 0 {
+		t.Fatalf("FuncIndex = %d, %v", idx, ok)
+	}
+	var b strings.Builder
+	if err := WriteWAT(&b, m, idx); err != nil {
+		t.Fatal(err)
+	}
+	want := `(func $_*T_.f (;0;) (type 0) (param $n i32) (result i32)
+  block
+    loop (result i32)
+      local.get $n
+      br_if 1
+      i32.const -1
+    end
+    br_table 0 1 0
+  end
+  local.get $n
+  if (type 1)
+    i32.const 1
+    i32.const 2
+  else
+    i32.const 3
+    i32.const 4
+  end
+  i32.add
+  i32.load offset=8 align=1
+  i32.store8
+  i32.extend8_s
+  i32.const 0
+  i32.const 0
+  i32.const 0
+  memory.copy
+  i32.const 0
+  i32.const 0
+  i32.const 0
+  memory.fill
+  i64.const -128
+  f64.const inf
+  f32.const nan
+  drop
+  drop
+  drop
+  call $_*T_.f
+)
+`
+	if got := b.String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestWriteWATErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		body []byte
+		want string
+	}{
+		{"unknown opcode", []byte{0xff, 0x0b}, "unknown opcode 0xff"},
+		{"unknown prefixed", []byte{0xfc, 0x7f, 0x0b}, "unknown instruction 0xfc 127"},
+		{"unclosed block", []byte{0x02, 0x40, 0x0b}, "function 0 ends inside a block"},
+		{"stray else", []byte{0x05, 0x0b}, "else outside of if"},
+		{"end in immediate", []byte{0x41, 0x0b}, "function 0 is missing its final end"},
+	}
+	for _, tt := range tests {
+		m, err := Parse(instructionModule(tt.body))
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		var b strings.Builder
+		if err := WriteWAT(&b, m); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: got %v, want an error containing %q", tt.name, err, tt.want)
+		}
+	}
+	m, _ := Parse(testModule())
+	if err := WriteWAT(&strings.Builder{}, m, 0); err == nil || err.Error() != "function 0 is not defined in this module" {
+		t.Errorf("imported function: got %v", err)
+	}
+}
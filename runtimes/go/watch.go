@@ -0,0 +1,162 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"slices"
+	"sort"
+	"time"
+)
+
+// fileState is the metadata watch compares between polls.
+type fileState struct {
+	size    int64
+	modTime time.Time
+	isDir   bool
+}
+
+type watchChange struct {
+	kind string // "created", "modified" or "removed"
+	path string
+}
+
+func registerWatch(r *registry) {
+	cmd := r.register(&command{
+		name:    "watch",
+		args:    "<paths...> -- <command> [args...]",
+		minArgs: 3,
+		maxArgs: -1,
+		summary: "Re-run a command whenever watched files change",
+	})
+	interval := cmd.flags.Duration("interval", 500*time.Millisecond, "time between polls of file metadata")
+	debounce := cmd.flags.Duration("debounce", 200*time.Millisecond, "quiet period to wait for after a change before running")
+	count := cmd.flags.Int("count", 0, "exit after running the command `N` times (0 watches forever)")
+
+	cmd.run = func(e *environment, args []string) int {
+		sep := slices.Index(args, "--")
+		if sep < 1 || sep == len(args)-1 {
+			return cmd.usageError(e, fmt.Sprintf("watch requires %s", cmd.args))
+		}
+		paths, cmdArgs := args[:sep], args[sep+1:]
+		if newRegistry().lookup(cmdArgs[0]) == nil {
+			fmt.Fprintf(e.stderr, "watch: unknown command: %s\n", cmdArgs[0])
+			return 127
+		}
+		if *interval <= 0 {
+			return cmd.usageError(e, "watch: --interval must be positive")
+		}
+		if *count < 0 {
+			return cmd.usageError(e, "watch: --count must not be negative")
+		}
+
+		prev, err := snapshot(e.fs, paths)
+		if err != nil {
+			fmt.Fprintf(e.stderr, "Error watching: %v\n", err)
+			return 1
+		}
+
+		code := 0
+		for runs := 0; *count == 0 || runs < *count; {
+			e.sleep(*interval)
+			cur, err := snapshot(e.fs, paths)
+			if err != nil {
+				fmt.Fprintf(e.stderr, "Error watching: %v\n", err)
+				return 1
+			}
+			if len(diffSnapshots(prev, cur)) == 0 {
+				continue
+			}
+
+			// Editors often save in several steps; wait for the burst to
+			// settle so the command runs once per save.
+			for quiet := time.Duration(0); quiet < *debounce; {
+				e.sleep(*interval)
+				next, err := snapshot(e.fs, paths)
+				if err != nil {
+					fmt.Fprintf(e.stderr, "Error watching: %v\n", err)
+					return 1
+				}
+				if len(diffSnapshots(cur, next)) == 0 {
+					quiet += *interval
+				} else {
+					quiet, cur = 0, next
+				}
+			}
+
+			changes := diffSnapshots(prev, cur)
+			prev = cur
+			if len(changes) == 0 {
+				continue
+			}
+			for _, c := range changes {
+				fmt.Fprintf(e.stderr, "watch: %s %s\n", c.kind, c.path)
+			}
+			// Each run gets fresh flag state, like a separate invocation.
+			code = newRegistry().lookup(cmdArgs[0]).execute(e, cmdArgs[1:])
+			runs++
+		}
+		return code
+	}
+}
+
+// snapshot records the state of every path, descending into directories.
+// Paths that do not exist yet are simply absent from the result.
+func snapshot(fsys fileSystem, paths []string) (map[string]fileState, error) {
+	states := make(map[string]fileState)
+	var walk func(p string) error
+	walk = func(p string) error {
+		info, err := fsys.Stat(p)
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		states[p] = fileState{size: info.Size(), modTime: info.ModTime(), isDir: info.IsDir()}
+		if !info.IsDir() {
+			return nil
+		}
+		entries, err := fsys.ReadDir(p)
+		if err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			if err := walk(path.Join(p, entry.Name())); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	for _, p := range paths {
+		if err := walk(path.Clean(p)); err != nil {
+			return nil, err
+		}
+	}
+	return states, nil
+}
+
+// diffSnapshots lists the files that differ between two snapshots, sorted
+// by path. Directory modification times are ignored since their contents
+// are compared directly.
+func diffSnapshots(old, cur map[string]fileState) []watchChange {
+	var changes []watchChange
+	for p, st := range cur {
+		prev, ok := old[p]
+		switch {
+		case !ok:
+			changes = append(changes, watchChange{kind: "created", path: p})
+		case st.isDir && prev.isDir:
+		case st != prev:
+			changes = append(changes, watchChange{kind: "modified", path: p})
+		}
+	}
+	for p := range old {
+		if _, ok := cur[p]; !ok {
+			changes = append(changes, watchChange{kind: "removed", path: p})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].path < changes[j].path })
+	return changes
+}
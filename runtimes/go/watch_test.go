@@ -0,0 +1,64 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestWatchRerunsOnChange(t *testing.T) {
+	fsys := newTestFS()
+	modified := time.Date(2026, 1, 2, 3, 5, 0, 0, time.UTC)
+
+	// Each entry is applied to the filesystem during the matching sleep.
+	script := []func(){
+		1: func() {
+			fsys.files["src/main.go"] = &fstest.MapFile{Data: []byte("package main // edited\n"), ModTime: modified}
+		},
+		2: func() { fsys.files["src/extra.go"] = &fstest.MapFile{Data: []byte("package main\n")} },
+		4: func() { delete(fsys.files, "src/util/str.go") },
+	}
+
+	var stdout, stderr bytes.Buffer
+	e := newTestEnvironment(fsys, &stdout, &stderr)
+	var slept []time.Duration
+	e.sleep = func(d time.Duration) {
+		if n := len(slept); n < len(script) && script[n] != nil {
+			script[n]()
+		}
+		slept = append(slept, d)
+	}
+
+	code := dispatch(e, []string{progName, "watch", "--count", "2", "--interval", "1s", "--debounce", "1s", "src", "missing.txt", "--", "echo", "rebuilt"})
+	got := fmt.Sprintf("exit: %d\n-- stdout --\n%s-- stderr --\n%s", code, stdout.String(), stderr.String())
+	checkGolden(t, "watch_rerun", got)
+
+	if len(slept) != 6 {
+		t.Errorf("slept %d times, want 6", len(slept))
+	}
+	for _, d := range slept {
+		if d != time.Second {
+			t.Errorf("slept %v, want the 1s interval", d)
+		}
+	}
+}
+
+func TestWatchGolden(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{"watch_no_separator", []string{"watch", "src", "echo", "hi"}},
+		{"watch_no_command", []string{"watch", "src", "hello.txt", "--"}},
+		{"watch_unknown_command", []string{"watch", "src", "--", "make", "all"}},
+		{"watch_bad_interval", []string{"watch", "--interval", "0s", "src", "--", "ls"}},
+		{"watch_negative_count", []string{"watch", "--count", "-1", "src", "--", "ls"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checkGolden(t, tt.name, transcript(newTestFS(), tt.args...))
+		})
+	}
+}
@@ -13,12 +13,12 @@ echo "======================"
 echo ""
 
 if [[ "${BUILD_GO}" == "true" ]]; then
-    if [[ -f "${PROJECT_ROOT}/runtimes/go/main.go" ]]; then
+    if [[ -f "${PROJECT_ROOT}/runtimes/go/go.mod" ]]; then
         echo "Building Go runtime..."
-        "${SCRIPT_DIR}/build-go.sh" "${PROJECT_ROOT}/runtimes/go/main.go"
+        "${SCRIPT_DIR}/build-go.sh" "${PROJECT_ROOT}/runtimes/go"
         echo ""
     else
-        echo "Skipping Go: runtimes/go/main.go not found"
+        echo "Skipping Go: runtimes/go/go.mod not found"
     fi
 fi
 
@@ -11,7 +11,7 @@ TINYGO_TARGET="${TINYGO_TARGET:-wasip1}"
 OPTIMIZE="${OPTIMIZE:-true}"
 
 usage() {
-    echo "Usage: $0 [options] <source_file>"
+    echo "Usage: $0 [options] <source_dir|source_file>"
     echo ""
     echo "Options:"
     echo "  -v, --version VERSION   Go version label (default: ${GO_VERSION})"
@@ -42,8 +42,14 @@ if [[ -z "${SOURCE_FILE}" ]]; then
     usage
 fi
 
-if [[ ! -f "${SOURCE_FILE}" ]]; then
-    echo "Error: Source file not found: ${SOURCE_FILE}"
+if [[ -d "${SOURCE_FILE}" ]]; then
+    SOURCE_DIR="$(cd "${SOURCE_FILE}" && pwd)"
+    BUILD_TARGET="."
+elif [[ -f "${SOURCE_FILE}" ]]; then
+    SOURCE_DIR="$(cd "$(dirname "${SOURCE_FILE}")" && pwd)"
+    BUILD_TARGET="$(basename "${SOURCE_FILE}")"
+else
+    echo "Error: Source not found: ${SOURCE_FILE}"
     exit 1
 fi
 
@@ -52,29 +58,86 @@ if ! command -v tinygo &> /dev/null; then
     exit 1
 fi
 
+if ! command -v go &> /dev/null; then
+    echo "Error: Go not found. Install Go or use Docker environment."
+    exit 1
+fi
+
 OUTPUT_NAME="${OUTPUT_NAME:-go-${GO_VERSION}.wasm}"
 OUTPUT_PATH="${BUILD_DIR}/${OUTPUT_NAME}"
 
 mkdir -p "${BUILD_DIR}"
 
+# Build metadata, compiled in through -ldflags -X and embedded in the
+# wasmhub.buildinfo custom section after optimization.
+# "tinygo version" prints e.g.
+#   tinygo version 0.33.0 linux/amd64 (using go version go1.23.0 and LLVM version 18.1.2)
+TINYGO_VERSION_LINE="$(tinygo version)"
+TINYGO_VERSION="$(sed -n 's/^tinygo version \([^ ]*\).*/\1/p' <<< "${TINYGO_VERSION_LINE}")"
+TOOLCHAIN_GO_VERSION="$(sed -n 's/.*using go version \([^ ]*\).*/\1/p' <<< "${TINYGO_VERSION_LINE}")"
+LLVM_VERSION="$(sed -n 's/.*LLVM version \([^)]*\)).*/\1/p' <<< "${TINYGO_VERSION_LINE}")"
+
+GIT_COMMIT="$(git -C "${PROJECT_ROOT}" rev-parse --short=12 HEAD 2>/dev/null || true)"
+if [[ -n "${GIT_COMMIT}" ]] && ! git -C "${PROJECT_ROOT}" diff --quiet HEAD 2>/dev/null; then
+    GIT_COMMIT="${GIT_COMMIT}-dirty"
+fi
+
+# SOURCE_DATE_EPOCH keeps rebuilds of the same commit byte-identical.
+if [[ -n "${SOURCE_DATE_EPOCH:-}" ]]; then
+    BUILD_DATE="$(date -u -d "@${SOURCE_DATE_EPOCH}" +%Y-%m-%dT%H:%M:%SZ 2>/dev/null \
+        || date -u -r "${SOURCE_DATE_EPOCH}" +%Y-%m-%dT%H:%M:%SZ)"
+else
+    BUILD_DATE="$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+fi
+
+OPTIMIZATION="opt=2 no-debug"
+RUN_WASM_OPT="false"
+if [[ "${OPTIMIZE}" == "true" ]] && command -v wasm-opt &> /dev/null; then
+    OPTIMIZATION="${OPTIMIZATION}, wasm-opt -O3"
+    RUN_WASM_OPT="true"
+fi
+
+BUILDINFO_PKG="github.com/anistark/wasmhub/runtimes/go/buildinfo"
+LDFLAGS="-X '${BUILDINFO_PKG}.version=${GO_VERSION}'"
+LDFLAGS+=" -X '${BUILDINFO_PKG}.tinygo=${TINYGO_VERSION}'"
+LDFLAGS+=" -X '${BUILDINFO_PKG}.llvm=${LLVM_VERSION}'"
+LDFLAGS+=" -X '${BUILDINFO_PKG}.target=${TINYGO_TARGET}'"
+LDFLAGS+=" -X '${BUILDINFO_PKG}.commit=${GIT_COMMIT}'"
+LDFLAGS+=" -X '${BUILDINFO_PKG}.date=${BUILD_DATE}'"
+LDFLAGS+=" -X '${BUILDINFO_PKG}.optimization=${OPTIMIZATION}'"
+
 echo "Building Go runtime..."
 echo "  Source: ${SOURCE_FILE}"
 echo "  Target: ${TINYGO_TARGET}"
+echo "  TinyGo: ${TINYGO_VERSION} (LLVM ${LLVM_VERSION})"
+echo "  Commit: ${GIT_COMMIT:-unknown}"
 echo "  Output: ${OUTPUT_PATH}"
 
-tinygo build \
+(cd "${SOURCE_DIR}" && tinygo build \
     -target="${TINYGO_TARGET}" \
     -opt=2 \
     -no-debug \
+    -ldflags="${LDFLAGS}" \
     -o "${OUTPUT_PATH}" \
-    "${SOURCE_FILE}"
+    "${BUILD_TARGET}")
 
-if [[ "${OPTIMIZE}" == "true" ]] && command -v wasm-opt &> /dev/null; then
+if [[ "${RUN_WASM_OPT}" == "true" ]]; then
     echo "Optimizing with wasm-opt..."
     wasm-opt -O3 "${OUTPUT_PATH}" -o "${OUTPUT_PATH}.opt"
     mv "${OUTPUT_PATH}.opt" "${OUTPUT_PATH}"
 fi
 
+(cd "${RUNTIMES_DIR}" && go run ./cmd/buildinfo embed \
+    --file "${OUTPUT_PATH}" \
+    --version "${GO_VERSION}" \
+    --go-version "${TOOLCHAIN_GO_VERSION}" \
+    --tinygo "${TINYGO_VERSION}" \
+    --llvm "${LLVM_VERSION}" \
+    --target "${TINYGO_TARGET}" \
+    --commit "${GIT_COMMIT}" \
+    --date "${BUILD_DATE}" \
+    --optimization "${OPTIMIZATION}")
+
 SIZE=$(stat -f%z "${OUTPUT_PATH}" 2>/dev/null || stat -c%s "${OUTPUT_PATH}")
 SHA256=$(shasum -a 256 "${OUTPUT_PATH}" | cut -d' ' -f1)
 
@@ -14,7 +14,7 @@ usage() {
     echo ""
     echo "Options:"
     echo "  --wasi VERSION          WASI version (default: wasip1)"
-    echo "  --features FEATURES     Comma-separated features"
+    echo "  --features FEATURES     Comma-separated features (default: derived from WASI imports)"
     echo "  -h, --help              Show this help"
     exit 1
 }
@@ -47,67 +47,24 @@ if [[ ! -f "${WASM_FILE}" ]]; then
     exit 1
 fi
 
-SIZE=$(stat -f%z "${WASM_FILE}" 2>/dev/null || stat -c%s "${WASM_FILE}")
-SHA256=$(shasum -a 256 "${WASM_FILE}" | cut -d' ' -f1)
-FILENAME=$(basename "${WASM_FILE}")
-RELEASED=$(date -u +"%Y-%m-%dT%H:%M:%SZ")
-
-RUNTIME_DIR=$(dirname "${WASM_FILE}")
-MANIFEST_FILE="${RUNTIME_DIR}/manifest.json"
-
-FEATURES_JSON="[]"
-if [[ -n "${FEATURES}" ]]; then
-    FEATURES_JSON=$(echo "${FEATURES}" | tr ',' '\n' | sed 's/^/"/;s/$/"/' | tr '\n' ',' | sed 's/,$//' | sed 's/^/[/;s/$/]/')
+if ! command -v go &> /dev/null; then
+    echo "Error: Go not found. Install Go or use Docker environment."
+    exit 1
 fi
 
-if [[ -f "${MANIFEST_FILE}" ]]; then
-    TMP_FILE=$(mktemp)
-
-    NEW_VERSION=$(cat <<EOF
-{
-    "file": "${FILENAME}",
-    "size": ${SIZE},
-    "sha256": "${SHA256}",
-    "released": "${RELEASED}",
-    "wasi": "${WASI_VERSION}",
-    "features": ${FEATURES_JSON}
-}
-EOF
+WASM_FILE="$(cd "$(dirname "${WASM_FILE}")" && pwd)/$(basename "${WASM_FILE}")"
+GENMANIFEST_ARGS=(
+    --language "${LANGUAGE}"
+    --version "${VERSION}"
+    --file "${WASM_FILE}"
+    --wasi "${WASI_VERSION}"
 )
-
-    if command -v jq &> /dev/null; then
-        jq --argjson newver "${NEW_VERSION}" \
-           --arg ver "${VERSION}" \
-           '.versions[$ver] = $newver | .latest = $ver' \
-           "${MANIFEST_FILE}" > "${TMP_FILE}"
-        mv "${TMP_FILE}" "${MANIFEST_FILE}"
-    else
-        echo "Warning: jq not found, cannot update existing manifest"
-        echo "New version metadata:"
-        echo "${NEW_VERSION}"
-    fi
-else
-    cat > "${MANIFEST_FILE}" <<EOF
-{
-    "language": "${LANGUAGE}",
-    "latest": "${VERSION}",
-    "versions": {
-        "${VERSION}": {
-            "file": "${FILENAME}",
-            "size": ${SIZE},
-            "sha256": "${SHA256}",
-            "released": "${RELEASED}",
-            "wasi": "${WASI_VERSION}",
-            "features": ${FEATURES_JSON}
-        }
-    }
-}
-EOF
+if [[ -n "${FEATURES}" ]]; then
+    GENMANIFEST_ARGS+=(--features "${FEATURES}")
 fi
 
-echo "Metadata generated:"
-echo "  Manifest: ${MANIFEST_FILE}"
-echo "  Language: ${LANGUAGE}"
-echo "  Version: ${VERSION}"
-echo "  Size: ${SIZE} bytes"
-echo "  SHA256: ${SHA256}"
+# The generator lives in the Go runtime's module; SOURCE_DATE_EPOCH is
+# passed through from the environment for reproducible release dates.
+cd "${PROJECT_ROOT}/runtimes/go"
+go run ./cmd/genmanifest update "${GENMANIFEST_ARGS[@]}"
+go run ./cmd/genmanifest global --root "${PROJECT_ROOT}"